@@ -41,7 +41,7 @@ func main() {
 	}
 
 	// Run migrations once at startup using a temporary UoW
-	if err = tracker.New(sqlDB).AutoMigrate(&Customer{}, &Order{}); err != nil {
+	if _, err = tracker.NewWithOptions(sqlDB, tracker.WithAutoMigrate(&Customer{}, &Order{})); err != nil {
 		log.Fatalf("failed to migrate database: %v", err)
 	}
 
@@ -98,7 +98,11 @@ func createCustomerHandler(sqlDB *sql.DB, w http.ResponseWriter, r *http.Request
 		req.O2 = 149.50
 	}
 
-	uow := tracker.New(sqlDB) // new instance per request
+	uow, err := tracker.NewWithOptions(sqlDB) // new instance per request
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	customer := &Customer{Name: req.Name, Email: req.Email}
 	uow.Add(customer)
 
@@ -114,14 +118,14 @@ func createCustomerHandler(sqlDB *sql.DB, w http.ResponseWriter, r *http.Request
 	})
 
 	// Save all pending work
-	if err := uow.SaveChanges(r.Context()); err != nil {
+	if err = uow.SaveChanges(r.Context()); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Load back with orders
 	var out Customer
-	if err := uow.PreloadFirst(r.Context(), &out, customer.ID, "Orders"); err != nil {
+	if err = uow.PreloadFirst(r.Context(), &out, customer.ID, "Orders"); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -141,7 +145,11 @@ func getCustomerHandler(sqlDB *sql.DB, w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid id", http.StatusBadRequest)
 		return
 	}
-	uow := tracker.New(sqlDB)
+	uow, err := tracker.NewWithOptions(sqlDB)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	var out Customer
 	if err = uow.PreloadFirst(r.Context(), &out, id, "Orders"); err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
@@ -161,7 +169,11 @@ func concurrentHandler(sqlDB *sql.DB, w http.ResponseWriter, r *http.Request) {
 	done := make(chan error, n)
 	for i := range n {
 		go func(i int) {
-			u := tracker.New(sqlDB)
+			u, err := tracker.NewWithOptions(sqlDB)
+			if err != nil {
+				done <- err
+				return
+			}
 			c := &Customer{
 				Name:  fmt.Sprintf("User %d", i),
 				Email: fmt.Sprintf("user%d+%d@example.com", i, time.Now().UnixNano()),
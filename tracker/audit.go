@@ -0,0 +1,52 @@
+package tracker
+
+import "context"
+
+// actorKey is the context key of the actor set by ContextWithActor.
+type actorKey struct{}
+
+// Auditable is implemented by models that record who wrote them. On commit, created
+// entities get SetCreatedBy and SetUpdatedBy, updated ones SetUpdatedBy, with the actor of
+// WithActor or of the commit's context, before their SQL is executed.
+type Auditable interface {
+	SetCreatedBy(actor string)
+	SetUpdatedBy(actor string)
+}
+
+// WithActor returns a scoped UnitOfWork whose commits stamp Auditable entities with
+// actorID, taking precedence over an actor in the commit's context.
+func (r *UnitOfWork) WithActor(actorID string) *UnitOfWork {
+	s := r.scoped(r.mustRoot())
+	s.opts.actor = actorID
+	return s
+}
+
+// ContextWithActor returns a context carrying actorID, typically set by HTTP middleware
+// after authentication, so commits under it stamp Auditable entities without WithActor.
+func ContextWithActor(ctx context.Context, actorID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actorID)
+}
+
+// ActorFromContext returns the actor set by ContextWithActor, if any.
+func ActorFromContext(ctx context.Context) (string, bool) {
+	actor, ok := ctx.Value(actorKey{}).(string)
+	return actor, ok
+}
+
+// stampActor records the commit's actor on an Auditable entity about to be written.
+func (r *UnitOfWork) stampActor(ctx context.Context, entity any, phase string) {
+	a, ok := entity.(Auditable)
+	if !ok {
+		return
+	}
+	actor := r.opts.actor
+	if actor == "" {
+		if actor, ok = ActorFromContext(ctx); !ok {
+			return
+		}
+	}
+	if phase == PhaseCreate {
+		a.SetCreatedBy(actor)
+	}
+	a.SetUpdatedBy(actor)
+}
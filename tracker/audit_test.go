@@ -0,0 +1,82 @@
+package tracker
+
+import (
+	"context"
+	"testing"
+)
+
+type auditedDoc struct {
+	Title     string
+	CreatedBy string
+	UpdatedBy string
+	ID        uint
+}
+
+func (d *auditedDoc) SetCreatedBy(actor string) { d.CreatedBy = actor }
+func (d *auditedDoc) SetUpdatedBy(actor string) { d.UpdatedBy = actor }
+
+func TestAuditActor(t *testing.T) {
+	tests := []struct {
+		scope       func(uow *UnitOfWork) *UnitOfWork
+		ctx         context.Context
+		name        string
+		wantCreated string
+		wantUpdated string
+	}{
+		{
+			name:        "context actor",
+			scope:       func(uow *UnitOfWork) *UnitOfWork { return uow },
+			ctx:         ContextWithActor(context.Background(), "alice"),
+			wantCreated: "alice", wantUpdated: "alice",
+		},
+		{
+			name:        "WithActor wins over context",
+			scope:       func(uow *UnitOfWork) *UnitOfWork { return uow.WithActor("bob") },
+			ctx:         ContextWithActor(context.Background(), "alice"),
+			wantCreated: "bob", wantUpdated: "bob",
+		},
+		{
+			name:  "no actor",
+			scope: func(uow *UnitOfWork) *UnitOfWork { return uow },
+			ctx:   context.Background(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := tt.scope(newTestUoW(t, []any{&auditedDoc{}}))
+			doc := &auditedDoc{Title: "draft"}
+			uow.Add(doc)
+			if err := uow.Commit(tt.ctx); err != nil {
+				t.Fatalf("Commit: %v", err)
+			}
+			var got auditedDoc
+			if err := uow.First(tt.ctx, &got, doc.ID); err != nil {
+				t.Fatalf("First: %v", err)
+			}
+			if got.CreatedBy != tt.wantCreated || got.UpdatedBy != tt.wantUpdated {
+				t.Fatalf("row = %+v, want CreatedBy %q UpdatedBy %q", got, tt.wantCreated, tt.wantUpdated)
+			}
+		})
+	}
+}
+
+func TestAuditActorOnUpdate(t *testing.T) {
+	uow := newTestUoW(t, []any{&auditedDoc{}})
+	doc := &auditedDoc{Title: "draft"}
+	uow.Add(doc)
+	if err := uow.Commit(ContextWithActor(context.Background(), "alice")); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	doc.Title = "final"
+	uow.Update(doc)
+	if err := uow.Commit(ContextWithActor(context.Background(), "bob")); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	var got auditedDoc
+	if err := uow.First(context.Background(), &got, doc.ID); err != nil {
+		t.Fatalf("First: %v", err)
+	}
+	if got.CreatedBy != "alice" || got.UpdatedBy != "bob" {
+		t.Fatalf("row = %+v, want CreatedBy alice UpdatedBy bob", got)
+	}
+}
@@ -0,0 +1,53 @@
+package tracker
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// BatchSize returns a scoped UnitOfWork whose commits insert consecutive created entities of
+// the same model with multi-row INSERT statements of at most n rows each, instead of one
+// INSERT per entity. Primary keys are still assigned to the entities. n <= 0 restores the
+// default of one statement per entity. WithBeforeSave and WithAfterSave hooks run for every
+// entity, but around the whole batch rather than around each row.
+func (r *UnitOfWork) BatchSize(n int) *UnitOfWork {
+	s := r.scoped(r.mustRoot())
+	s.opts.batchSize = max(n, 0)
+	return s
+}
+
+// createGroups splits creates into the groups inserted together: runs of consecutive
+// pointers to the same struct type under BatchSize, single entities otherwise.
+func (r *UnitOfWork) createGroups(creates []any) [][]any {
+	var groups [][]any
+	for i, e := range creates {
+		if i > 0 && r.opts.batchSize > 0 && batchable(e) {
+			last := groups[len(groups)-1]
+			if reflect.TypeOf(last[0]) == reflect.TypeOf(e) {
+				groups[len(groups)-1] = append(last, e)
+				continue
+			}
+		}
+		groups = append(groups, []any{e})
+	}
+	return groups
+}
+
+// batchable reports whether e can be inserted as an element of a slice of its type.
+func batchable(e any) bool {
+	t := reflect.TypeOf(e)
+	return t != nil && t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct
+}
+
+// createGroup inserts a group from createGroups.
+func (r *UnitOfWork) createGroup(tx *gorm.DB, group []any) error {
+	if len(group) == 1 {
+		return tx.Create(group[0]).Error
+	}
+	rows := reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(group[0])), len(group), len(group))
+	for i, e := range group {
+		rows.Index(i).Set(reflect.ValueOf(e))
+	}
+	return tx.CreateInBatches(rows.Interface(), r.opts.batchSize).Error
+}
@@ -0,0 +1,109 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type batchRow struct {
+	Name string
+	ID   uint
+}
+
+// countInserts counts the INSERT statements uow's root sends from now on.
+func countInserts(t testing.TB, uow *UnitOfWork) *int {
+	t.Helper()
+	n := new(int)
+	err := uow.mustRoot().Callback().Create().After("gorm:create").Register("test:count_inserts", func(*gorm.DB) { *n++ })
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+	return n
+}
+
+func TestBatchSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		batchSize  int
+		wantInsert int
+	}{
+		{name: "default", batchSize: 0, wantInsert: 1000},
+		{name: "300", batchSize: 300, wantInsert: 4},
+		{name: "larger than queue", batchSize: 5000, wantInsert: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&batchRow{}})
+			inserts := countInserts(t, uow)
+			b := uow.BatchSize(tt.batchSize)
+			rows := make([]*batchRow, 1000)
+			for i := range rows {
+				rows[i] = &batchRow{Name: fmt.Sprint("row", i)}
+				b.Add(rows[i])
+			}
+			mustCommit(t, b)
+
+			if *inserts != tt.wantInsert {
+				t.Fatalf("INSERT statements = %d, want %d", *inserts, tt.wantInsert)
+			}
+			for i, row := range rows {
+				if row.ID == 0 {
+					t.Fatalf("rows[%d].ID not assigned", i)
+				}
+			}
+		})
+	}
+}
+
+func TestFluentConfigReturnsScopedCopy(t *testing.T) {
+	tests := []struct {
+		apply func(*UnitOfWork) *UnitOfWork
+		name  string
+	}{
+		{name: "BatchSize", apply: func(u *UnitOfWork) *UnitOfWork { return u.BatchSize(10) }},
+		{name: "RetryIfConflict", apply: func(u *UnitOfWork) *UnitOfWork { return u.RetryIfConflict(3) }},
+		{name: "DeferredConstraintCheck", apply: (*UnitOfWork).DeferredConstraintCheck},
+		{name: "WithStrictMode", apply: (*UnitOfWork).WithStrictMode},
+		{name: "LogOperations", apply: func(u *UnitOfWork) *UnitOfWork { return u.LogOperations(io.Discard) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&batchRow{}})
+			before := fmt.Sprintf("%d %d %t %v %d", uow.opts.batchSize, uow.opts.maxRetries, uow.opts.strict,
+				uow.opts.planWriter, len(uow.opts.onBegin))
+			if got := tt.apply(uow); got == uow {
+				t.Fatal("returned the receiver, want a scoped copy")
+			}
+			after := fmt.Sprintf("%d %d %t %v %d", uow.opts.batchSize, uow.opts.maxRetries, uow.opts.strict,
+				uow.opts.planWriter, len(uow.opts.onBegin))
+			if before != after {
+				t.Fatalf("receiver options changed: %s -> %s", before, after)
+			}
+		})
+	}
+}
+
+func BenchmarkCommitCreates(b *testing.B) {
+	for _, size := range []int{0, 100, 500} {
+		name := "single"
+		if size > 0 {
+			name = fmt.Sprint("batch", size)
+		}
+		b.Run(name, func(b *testing.B) {
+			uow := newTestUoW(b, []any{&batchRow{}}).BatchSize(size)
+			ctx := context.Background()
+			for b.Loop() {
+				for i := range 1000 {
+					uow.Add(&batchRow{Name: fmt.Sprint("row", i)})
+				}
+				if err := uow.Commit(ctx); err != nil {
+					b.Fatalf("Commit: %v", err)
+				}
+			}
+		})
+	}
+}
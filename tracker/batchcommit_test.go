@@ -0,0 +1,64 @@
+package tracker
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type batchItem struct {
+	Name string
+	ID   uint
+}
+
+func TestBatchCommit(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		entities  int
+		batchSize int
+		wantTx    int
+	}{
+		{name: "three chunks", entities: 250, batchSize: 100, wantTx: 3},
+		{name: "one chunk", entities: 50, batchSize: 100, wantTx: 1},
+		{name: "no batching", entities: 250, batchSize: 0, wantTx: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&batchItem{}}).Clone()
+			txs := 0
+			uow.opts.addOnBegin(func(*gorm.DB) error { txs++; return nil })
+			for range tt.entities {
+				uow.Add(&batchItem{Name: "x"})
+			}
+			if err := uow.BatchCommit(ctx, tt.batchSize); err != nil {
+				t.Fatalf("BatchCommit: %v", err)
+			}
+			if txs != tt.wantTx {
+				t.Fatalf("transactions = %d, want %d", txs, tt.wantTx)
+			}
+			if n, err := uow.Count(ctx, &batchItem{}); err != nil || n != int64(tt.entities) {
+				t.Fatalf("Count = %d, %v, want %d", n, err, tt.entities)
+			}
+			if uow.HasPending() {
+				t.Fatal("queue not cleared")
+			}
+		})
+	}
+}
+
+func TestBatchCommitLogsPlan(t *testing.T) {
+	var plan bytes.Buffer
+	uow := newTestUoW(t, []any{&batchItem{}}).LogOperations(&plan)
+	uow.Add(&batchItem{Name: "a"})
+	uow.Add(&batchItem{Name: "b"})
+	if err := uow.BatchCommit(context.Background(), 1); err != nil {
+		t.Fatalf("BatchCommit: %v", err)
+	}
+	if got := strings.Count(plan.String(), "CREATE batchItem"); got != 2 {
+		t.Fatalf("plan = %q, want two CREATE lines", plan.String())
+	}
+}
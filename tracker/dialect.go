@@ -0,0 +1,113 @@
+package tracker
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Dialect names a database dialect registered with RegisterDialect.
+type Dialect string
+
+// Well-known dialects. Only DialectSQLite is registered by default: the module does not
+// depend on the PostgreSQL or MySQL GORM drivers, so applications using those databases
+// import gojogo/tracker/postgres or gojogo/tracker/mysql, separate modules that register
+// them, or call RegisterDialect themselves, before creating UoWs on them.
+const (
+	DialectSQLite   Dialect = "sqlite"
+	DialectPostgres Dialect = "postgres"
+	DialectMySQL    Dialect = "mysql"
+)
+
+// driverDialects maps database/sql driver package paths to their dialect.
+var driverDialects = map[string]Dialect{
+	"github.com/mattn/go-sqlite3":    DialectSQLite,
+	"modernc.org/sqlite":             DialectSQLite,
+	"github.com/jackc/pgx/v5/stdlib": DialectPostgres,
+	"github.com/jackc/pgx/v4/stdlib": DialectPostgres,
+	"github.com/lib/pq":              DialectPostgres,
+	"github.com/go-sql-driver/mysql": DialectMySQL,
+}
+
+// dialects maps the names accepted by NewDialect to dialector constructors.
+var dialects sync.Map
+
+func init() {
+	RegisterDialect("sqlite", func(db *sql.DB) gorm.Dialector { return sqlite.Dialector{Conn: db} })
+}
+
+// RegisterDialect makes a GORM dialector available to NewDialect under name, replacing any
+// previous registration. Only "sqlite" is built in, so the tracker does not pull in other
+// database drivers; import gojogo/tracker/postgres or gojogo/tracker/mysql, or register
+// the ones you use at startup, e.g.
+//
+//	tracker.RegisterDialect("postgres", func(db *sql.DB) gorm.Dialector {
+//		return postgres.New(postgres.Config{Conn: db})
+//	})
+func RegisterDialect(name string, open func(db *sql.DB) gorm.Dialector) {
+	dialects.Store(name, open)
+}
+
+// NewDialect creates a UnitOfWork on sqlDB using the dialector registered under dialect
+// ("sqlite", or e.g. "postgres" or "mysql" once registered with RegisterDialect). An unknown
+// dialect returns an error wrapping ErrUnsupportedDialect.
+func NewDialect(sqlDB *sql.DB, dialect string, opts ...Option) (*UnitOfWork, error) {
+	open, ok := dialects.Load(dialect)
+	if !ok {
+		return nil, fmt.Errorf("tracker: dialect %q is not registered: %w", dialect, ErrUnsupportedDialect)
+	}
+	o := newOptions(opts)
+	o.dialect = dialect
+	o.dialector = open.(func(*sql.DB) gorm.Dialector)(sqlDB)
+	uow, err := newUnitOfWork(sqlDB, o)
+	if err != nil {
+		return nil, err
+	}
+	return uow, nil
+}
+
+// NewWithDialect is NewDialect taking a Dialect. With an empty dialect it is detected from
+// sqlDB's driver (mattn/go-sqlite3, pgx, lib/pq or go-sql-driver/mysql); the dialect must
+// still be registered, e.g. DialectPostgres with a postgres.New dialector (see
+// RegisterDialect). An undetectable or unregistered dialect returns an error wrapping
+// ErrUnsupportedDialect.
+func NewWithDialect(sqlDB *sql.DB, dialect Dialect, opts ...Option) (*UnitOfWork, error) {
+	if dialect == "" {
+		var err error
+		if dialect, err = detectDialect(sqlDB); err != nil {
+			return nil, err
+		}
+	}
+	return NewDialect(sqlDB, string(dialect), opts...)
+}
+
+// detectDialect maps sqlDB's driver to a dialect by the driver's package path.
+func detectDialect(sqlDB *sql.DB) (Dialect, error) {
+	t := reflect.TypeOf(sqlDB.Driver())
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if d, ok := driverDialects[t.PkgPath()]; ok {
+		return d, nil
+	}
+	return "", fmt.Errorf("tracker: cannot detect the dialect of driver %s: %w", t, ErrUnsupportedDialect)
+}
+
+// defaultDialector returns the dialector New and NewWithOptions open sqlDB with: the one
+// registered for the dialect of sqlDB's driver, or SQLite for drivers it does not recognize.
+// A recognized but unregistered dialect returns an error wrapping ErrUnsupportedDialect.
+func defaultDialector(sqlDB *sql.DB) (gorm.Dialector, error) {
+	dialect, err := detectDialect(sqlDB)
+	if err != nil {
+		return sqlite.Dialector{Conn: sqlDB}, nil
+	}
+	open, ok := dialects.Load(string(dialect))
+	if !ok {
+		return nil, fmt.Errorf("tracker: dialect %q of the database driver is not registered: %w", dialect, ErrUnsupportedDialect)
+	}
+	return open.(func(*sql.DB) gorm.Dialector)(sqlDB), nil
+}
@@ -0,0 +1,62 @@
+package tracker
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+// fakeDriver is a database/sql driver whose package path the tests map to a dialect.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return nil, errors.New("fake driver") }
+
+func init() { sql.Register("tracker-fake", fakeDriver{}) }
+
+func TestNewWithDialect(t *testing.T) {
+	tests := []struct {
+		wantErr error
+		name    string
+		dialect Dialect
+		want    string
+	}{
+		{name: "detected from driver", dialect: "", want: "sqlite"},
+		{name: "explicit sqlite", dialect: DialectSQLite, want: "sqlite"},
+		{name: "unregistered postgres", dialect: DialectPostgres, wantErr: ErrUnsupportedDialect},
+		{name: "unknown", dialect: "oracle", wantErr: ErrUnsupportedDialect},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow, err := NewWithDialect(newTestDB(t), tt.dialect)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewWithDialect: %v", err)
+			}
+			if got := uow.mustRoot().Dialector.Name(); got != tt.want {
+				t.Fatalf("dialect = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDetectsDialect(t *testing.T) {
+	if _, err := NewWithOptions(newTestDB(t)); err != nil {
+		t.Fatalf("sqlite driver: %v", err)
+	}
+
+	driverDialects["gojogo/tracker"] = DialectMySQL
+	t.Cleanup(func() { delete(driverDialects, "gojogo/tracker") })
+	db, err := sql.Open("tracker-fake", "")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if _, err = NewWithOptions(db); !errors.Is(err, ErrUnsupportedDialect) {
+		t.Fatalf("unregistered dialect: err = %v, want ErrUnsupportedDialect", err)
+	}
+}
@@ -0,0 +1,68 @@
+package tracker
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Patterns for the passwords of connection strings that are not URLs.
+var (
+	// keyValuePassword matches password=... in libpq key/value DSNs.
+	keyValuePassword = regexp.MustCompile(`(?i)(\bpassword\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+	// userPassword matches the user:password@ prefix of MySQL DSNs.
+	userPassword = regexp.MustCompile(`^([^:@/]*):[^@]*@`)
+)
+
+// ConnectionString returns the connection string of the UnitOfWork for logging, with any
+// password replaced by ***. For SQLite it is the database file path. The DSN is known when
+// the UoW was created by NewFromDSN or with WithDSN; otherwise, on other dialects, it is
+// empty.
+func (r *UnitOfWork) ConnectionString() string {
+	if r.root != nil && r.root.Dialector.Name() == "sqlite" {
+		return r.sqlitePath()
+	}
+	return redactDSN(r.opts.dsn)
+}
+
+// sqlitePath returns the file of the main SQLite database, from the DSN when known.
+func (r *UnitOfWork) sqlitePath() string {
+	if r.opts.dsn != "" {
+		path, _, _ := strings.Cut(strings.TrimPrefix(r.opts.dsn, "file:"), "?")
+		return path
+	}
+	var rows []struct {
+		Name string
+		File string
+	}
+	if err := r.root.Raw("PRAGMA database_list").Scan(&rows).Error; err != nil {
+		return ""
+	}
+	for _, db := range rows {
+		if db.Name == "main" {
+			return db.File
+		}
+	}
+	return ""
+}
+
+// redactDSN replaces the password in a URL, key/value or MySQL connection string.
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" && u.Host != "" {
+		if u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				u.User = url.UserPassword(u.User.Username(), "***")
+			}
+		}
+		if q := u.Query(); q.Has("password") {
+			q.Set("password", "***")
+			u.RawQuery = q.Encode()
+		}
+		// url escapes the asterisks, which are easier to spot as is.
+		return strings.ReplaceAll(u.String(), "%2A%2A%2A", "***")
+	}
+	if keyValuePassword.MatchString(dsn) {
+		return keyValuePassword.ReplaceAllString(dsn, "${1}***")
+	}
+	return userPassword.ReplaceAllString(dsn, "${1}:***@")
+}
@@ -0,0 +1,248 @@
+package tracker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// importBatchSize is how many rows Import inserts per statement.
+const importBatchSize = 500
+
+// dumpHeader is the first NDJSON line Export writes for each table.
+type dumpHeader struct {
+	Table  string     `json:"table"`
+	Schema dumpSchema `json:"schema"`
+}
+
+// dumpSchema describes the columns of an exported table.
+type dumpSchema struct {
+	Model      string   `json:"model"`
+	Columns    []string `json:"columns"`
+	PrimaryKey []string `json:"primary_key"`
+}
+
+// dumpRow is one exported row, keyed by column name.
+type dumpRow struct {
+	Row map[string]any `json:"row"`
+}
+
+// dumpLine is any line of a dump: a header when Table is set, a row otherwise.
+type dumpLine struct {
+	Table string         `json:"table"`
+	Row   map[string]any `json:"row"`
+}
+
+// ImportResult summarizes what Import restored.
+type ImportResult struct {
+	TablesImported int
+	RowsImported   int64
+	// Tables holds the number of rows imported per table.
+	Tables map[string]int64
+}
+
+// Export streams the rows of each model's table to w as NDJSON: a
+// {"table":...,"schema":{...}} header line per table, followed by one {"row":{...}} line per
+// row in primary-key order. Binary columns are base64-encoded. Soft-deleted rows are included. Rows are read through a cursor,
+// so memory use does not grow with table size.
+func (r *UnitOfWork) Export(ctx context.Context, w io.Writer, models ...any) error {
+	enc := json.NewEncoder(w)
+	for _, model := range models {
+		if err := r.exportTable(ctx, enc, model); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportTable writes the header and rows of model's table.
+func (r *UnitOfWork) exportTable(ctx context.Context, enc *json.Encoder, model any) error {
+	db := r.conn(ctx)
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return fmt.Errorf("tracker: export %T: %w", model, err)
+	}
+	sch := stmt.Schema
+	h := dumpHeader{Table: sch.Table, Schema: dumpSchema{Model: sch.Name, Columns: sch.DBNames}}
+	for _, f := range sch.PrimaryFields {
+		h.Schema.PrimaryKey = append(h.Schema.PrimaryKey, f.DBName)
+	}
+	if err := enc.Encode(h); err != nil {
+		return err
+	}
+
+	q := db.Model(model).Unscoped()
+	for _, pk := range h.Schema.PrimaryKey {
+		q = q.Order(clause.OrderByColumn{Column: clause.Column{Name: pk}})
+	}
+	rows, err := q.Rows()
+	if err != nil {
+		return fmt.Errorf("tracker: export %s: %w", sch.Table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		row := map[string]any{}
+		if err = db.ScanRows(rows, &row); err != nil {
+			return fmt.Errorf("tracker: export %s: %w", sch.Table, err)
+		}
+		for _, f := range sch.Fields {
+			if s, ok := row[f.DBName].(string); ok && f.DataType == schema.Bytes {
+				row[f.DBName] = []byte(s) // encoded as base64, binary data is not valid UTF-8
+			}
+		}
+		if err = enc.Encode(dumpRow{Row: row}); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Import restores a dump written by Export, in a single transaction. Each table must belong
+// to a model registered with WithModels or WithAutoMigrate; its values are converted back to
+// the model's column types and inserted in batches, keeping their primary keys. Model hooks
+// do not run. On error nothing is imported and the partial counts are returned.
+func (r *UnitOfWork) Import(ctx context.Context, rd io.Reader) (ImportResult, error) {
+	res := ImportResult{Tables: map[string]int64{}}
+	db := r.conn(ctx)
+	registry, err := r.modelRegistry(db)
+	if err != nil {
+		return res, err
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var (
+			model any
+			sch   *schema.Schema
+			batch []map[string]any
+		)
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			// Create may append RETURNING rows to batch, so count it first.
+			n := int64(len(batch))
+			if err := tx.Model(model).Create(&batch).Error; err != nil {
+				return fmt.Errorf("tracker: import %s: %w", sch.Table, err)
+			}
+			res.RowsImported += n
+			res.Tables[sch.Table] += n
+			batch = nil
+			return nil
+		}
+
+		sc := bufio.NewScanner(rd)
+		sc.Buffer(nil, 64<<20)
+		for sc.Scan() {
+			var line dumpLine
+			dec := json.NewDecoder(bytes.NewReader(sc.Bytes()))
+			dec.UseNumber()
+			if err := dec.Decode(&line); err != nil {
+				return fmt.Errorf("tracker: import: %w", err)
+			}
+			if line.Table != "" {
+				if err := flush(); err != nil {
+					return err
+				}
+				m, ok := registry[line.Table]
+				if !ok {
+					return fmt.Errorf("tracker: import: no model registered for table %q", line.Table)
+				}
+				model, sch = m.model, m.schema
+				res.TablesImported++
+				if _, seen := res.Tables[sch.Table]; !seen {
+					res.Tables[sch.Table] = 0
+				}
+				continue
+			}
+			if sch == nil {
+				return errors.New("tracker: import: row before any table header")
+			}
+			row, err := importRow(sch, line.Row)
+			if err != nil {
+				return fmt.Errorf("tracker: import %s: %w", sch.Table, err)
+			}
+			if batch = append(batch, row); len(batch) >= importBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return fmt.Errorf("tracker: import: %w", err)
+		}
+		return flush()
+	})
+	return res, err
+}
+
+// registeredModel is a model Import can restore, with its parsed schema.
+type registeredModel struct {
+	model  any
+	schema *schema.Schema
+}
+
+// modelRegistry maps table names to the models registered on r.
+func (r *UnitOfWork) modelRegistry(db *gorm.DB) (map[string]registeredModel, error) {
+	out := map[string]registeredModel{}
+	for _, model := range append(slices.Clip(r.opts.models), r.opts.migrate...) {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, fmt.Errorf("tracker: import: register %T: %w", model, err)
+		}
+		out[stmt.Schema.Table] = registeredModel{model: model, schema: stmt.Schema}
+	}
+	return out, nil
+}
+
+// importRow converts decoded JSON values to what sch's columns expect: json.Number to an
+// integer or float, RFC 3339 strings to time.Time for time columns, and base64 strings to
+// bytes for binary columns.
+func importRow(sch *schema.Schema, row map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(row))
+	for col, v := range row {
+		f := sch.LookUpField(col)
+		if f == nil {
+			return nil, fmt.Errorf("unknown column %q", col)
+		}
+		switch x := v.(type) {
+		case json.Number:
+			var err error
+			switch f.DataType {
+			case schema.Int, schema.Uint:
+				v, err = x.Int64()
+			default:
+				v, err = x.Float64()
+			}
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", col, err)
+			}
+		case string:
+			switch f.DataType {
+			case schema.Time:
+				t, err := time.Parse(time.RFC3339Nano, x)
+				if err != nil {
+					return nil, fmt.Errorf("column %q: %w", col, err)
+				}
+				v = t
+			case schema.Bytes:
+				b, err := base64.StdEncoding.DecodeString(x)
+				if err != nil {
+					return nil, fmt.Errorf("column %q: %w", col, err)
+				}
+				v = b
+			}
+		}
+		out[f.DBName] = v
+	}
+	return out, nil
+}
@@ -0,0 +1,55 @@
+package tracker
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+type dumpDoc struct {
+	CreatedAt time.Time
+	Title     string
+	Blob      []byte
+	ID        uint
+	Size      int
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		doc  dumpDoc
+	}{
+		{name: "binary blob", doc: dumpDoc{Title: "bin", Blob: []byte{0, 1, 2, 255}, Size: 4}},
+		{name: "text blob", doc: dumpDoc{Title: "text", Blob: []byte("héllo"), Size: 6}},
+		{name: "empty blob", doc: dumpDoc{Title: "empty"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := newTestUoW(t, []any{&dumpDoc{}})
+			doc := tt.doc
+			src.Add(&doc)
+			mustCommit(t, src)
+
+			var buf bytes.Buffer
+			if err := src.Export(ctx, &buf, &dumpDoc{}); err != nil {
+				t.Fatalf("Export: %v", err)
+			}
+			dst := newTestUoW(t, []any{&dumpDoc{}})
+			res, err := dst.Import(ctx, &buf)
+			if err != nil || res.RowsImported != 1 {
+				t.Fatalf("Import = %+v, %v", res, err)
+			}
+
+			var got dumpDoc
+			if err := dst.First(ctx, &got, doc.ID); err != nil {
+				t.Fatalf("First: %v", err)
+			}
+			if !bytes.Equal(got.Blob, doc.Blob) || got.Title != doc.Title || got.Size != doc.Size ||
+				!got.CreatedAt.Equal(doc.CreatedAt) {
+				t.Fatalf("imported %+v, want %+v", got, doc)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package tracker
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// encryptionSetting is the statement setting that carries a scope's columnEncryption.
+const encryptionSetting = "tracker:column_encryption"
+
+// encryptionHooks records the GORM callback registries the encryption callbacks were
+// installed on.
+var encryptionHooks sync.Map
+
+// columnEncryption encrypts a set of string fields with AES-GCM.
+type columnEncryption struct {
+	aead cipher.AEAD
+	cols []string
+}
+
+// WithColumnEncryption returns a scoped UnitOfWork that encrypts cols, string fields given by
+// Go field or column name, with AES-GCM before they are written and decrypts them after
+// they are read, so they are stored as base64 ciphertext. Entities hold plaintext before
+// and after a write. key must be 16, 24 or 32 bytes long.
+//
+// Only writes and reads of whole entities are covered (commits, First, Find, ...); values
+// passed as column updates or conditions are used as is, so encrypted columns cannot be
+// searched.
+func (r *UnitOfWork) WithColumnEncryption(key []byte, cols []string) (*UnitOfWork, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("tracker: WithColumnEncryption: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("tracker: WithColumnEncryption: %w", err)
+	}
+	enc := &columnEncryption{aead: aead, cols: cols}
+	return r.scoped(r.mustRoot().Set(encryptionSetting, enc).Session(&gorm.Session{})), nil
+}
+
+// installEncryptionHooks registers the encryption callbacks on db's registry, once, when
+// the root is opened. They do nothing for statements without the encryption setting.
+func installEncryptionHooks(db *gorm.DB) error {
+	cb := db.Callback()
+	if _, done := encryptionHooks.LoadOrStore(cb, true); done {
+		return nil
+	}
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("tracker:encrypt_create", encryptFields),
+		cb.Create().After("gorm:create").Register("tracker:restore_create", restoreFields),
+		cb.Update().Before("gorm:update").Register("tracker:encrypt_update", encryptFields),
+		cb.Update().After("gorm:update").Register("tracker:restore_update", restoreFields),
+		cb.Query().After("gorm:query").Register("tracker:decrypt_query", decryptFields),
+	)
+}
+
+// plaintextKey is the instance setting holding the values encryptFields replaced.
+const plaintextKey = "tracker:column_plaintext"
+
+// plaintext is an entity field's value before encryption.
+type plaintext struct {
+	field *schema.Field
+	rv    reflect.Value
+	value any
+}
+
+// encryptFields encrypts the configured fields of the entities being written, remembering
+// their plaintext for restoreFields.
+func encryptFields(db *gorm.DB) {
+	enc, fields := encryptedFields(db)
+	if enc == nil {
+		return
+	}
+	ctx := db.Statement.Context
+	var saved []plaintext
+	defer func() { db.InstanceSet(plaintextKey, saved) }()
+	for _, rv := range structValues(db.Statement.ReflectValue) {
+		for _, f := range fields {
+			v, zero := f.ValueOf(ctx, rv)
+			if zero {
+				continue
+			}
+			out, err := enc.seal(reflect.ValueOf(v).String())
+			if err == nil {
+				err = setString(ctx, f, rv, out)
+			}
+			if err != nil {
+				_ = db.AddError(fmt.Errorf("tracker: encrypt %s.%s: %w", db.Statement.Schema.Name, f.Name, err))
+				return
+			}
+			saved = append(saved, plaintext{field: f, rv: rv, value: v})
+		}
+	}
+}
+
+// restoreFields puts the plaintext back into the written entities, whether or not the
+// write succeeded.
+func restoreFields(db *gorm.DB) {
+	v, ok := db.InstanceGet(plaintextKey)
+	if !ok {
+		return
+	}
+	for _, p := range v.([]plaintext) {
+		_ = p.field.Set(db.Statement.Context, p.rv, p.value)
+	}
+}
+
+// decryptFields decrypts the configured fields of the entities just read.
+func decryptFields(db *gorm.DB) {
+	enc, fields := encryptedFields(db)
+	if enc == nil || db.Error != nil {
+		return
+	}
+	ctx := db.Statement.Context
+	for _, rv := range structValues(db.Statement.ReflectValue) {
+		for _, f := range fields {
+			v, zero := f.ValueOf(ctx, rv)
+			if zero {
+				continue
+			}
+			out, err := enc.open(reflect.ValueOf(v).String())
+			if err == nil {
+				err = setString(ctx, f, rv, out)
+			}
+			if err != nil {
+				_ = db.AddError(fmt.Errorf("tracker: decrypt %s.%s: %w", db.Statement.Schema.Name, f.Name, err))
+				return
+			}
+		}
+	}
+}
+
+// encryptedFields returns the statement's encryption scope and the fields of its model it
+// covers, or nil when the statement is not scoped by WithColumnEncryption.
+func encryptedFields(db *gorm.DB) (*columnEncryption, []*schema.Field) {
+	v, ok := db.Get(encryptionSetting)
+	if !ok || db.Statement.Schema == nil {
+		return nil, nil
+	}
+	enc := v.(*columnEncryption)
+	var fields []*schema.Field
+	for _, col := range enc.cols {
+		f := db.Statement.Schema.LookUpField(col)
+		if f == nil {
+			continue
+		}
+		if f.FieldType.Kind() != reflect.String {
+			_ = db.AddError(fmt.Errorf("tracker: encrypt %s.%s: only string fields are supported", db.Statement.Schema.Name, f.Name))
+			return nil, nil
+		}
+		fields = append(fields, f)
+	}
+	return enc, fields
+}
+
+// seal encrypts s as base64(nonce || ciphertext).
+func (e *columnEncryption) seal(s string) (string, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(e.aead.Seal(nonce, nonce, []byte(s), nil)), nil
+}
+
+// open decrypts a value produced by seal.
+func (e *columnEncryption) open(s string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < e.aead.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	nonce, sealed := data[:e.aead.NonceSize()], data[e.aead.NonceSize():]
+	plain, err := e.aead.Open(nil, nonce, sealed, nil)
+	return string(plain), err
+}
+
+// setString assigns s to a string field, converting to named string types.
+func setString(ctx context.Context, f *schema.Field, rv reflect.Value, s string) error {
+	return f.Set(ctx, rv, reflect.ValueOf(s).Convert(f.FieldType).Interface())
+}
@@ -0,0 +1,114 @@
+package tracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"sync"
+	"testing"
+)
+
+type secretNote struct {
+	Title string
+	Body  string
+	ID    uint
+}
+
+var testKey = bytes.Repeat([]byte{7}, 32)
+
+func TestWithColumnEncryptionKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     []byte
+		wantErr bool
+	}{
+		{name: "AES-128", key: testKey[:16]},
+		{name: "AES-256", key: testKey},
+		{name: "bad length", key: testKey[:5], wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&secretNote{}})
+			enc, err := uow.WithColumnEncryption(tt.key, []string{"Body"})
+			if (err != nil) != tt.wantErr || (enc == nil) != tt.wantErr {
+				t.Fatalf("WithColumnEncryption = %v, %v, wantErr %t", enc, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestColumnEncryptionRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		cols []string
+	}{
+		{name: "field name", cols: []string{"Body"}},
+		{name: "column name", cols: []string{"body"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&secretNote{}})
+			enc, err := uow.WithColumnEncryption(testKey, tt.cols)
+			if err != nil {
+				t.Fatalf("WithColumnEncryption: %v", err)
+			}
+			note := &secretNote{Title: "plain", Body: "top secret"}
+			enc.Add(note)
+			mustCommit(t, enc)
+			if note.Body != "top secret" {
+				t.Fatalf("entity Body = %q after the write, want plaintext", note.Body)
+			}
+
+			var raw secretNote
+			if err := uow.First(ctx, &raw, note.ID); err != nil {
+				t.Fatalf("First: %v", err)
+			}
+			if raw.Body == note.Body || raw.Title != "plain" {
+				t.Fatalf("stored row = %+v, want Body encrypted and Title plain", raw)
+			}
+			if _, err := base64.StdEncoding.DecodeString(raw.Body); err != nil {
+				t.Fatalf("stored Body %q is not base64 ciphertext: %v", raw.Body, err)
+			}
+
+			note.Body = "still secret"
+			enc.Update(note)
+			mustCommit(t, enc)
+			var got secretNote
+			if err := enc.First(ctx, &got, note.ID); err != nil {
+				t.Fatalf("First: %v", err)
+			}
+			if got.Body != "still secret" {
+				t.Fatalf("decrypted Body = %q, want %q", got.Body, "still secret")
+			}
+		})
+	}
+}
+
+// TestColumnEncryptionConcurrentScopes creates encryption scopes while other goroutines
+// commit on the same root; run with -race.
+func TestColumnEncryptionConcurrentScopes(t *testing.T) {
+	uow := newTestUoW(t, []any{&secretNote{}})
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Go(func() {
+			enc, err := uow.WithColumnEncryption(testKey, []string{"Body"})
+			if err != nil {
+				t.Errorf("WithColumnEncryption: %v", err)
+				return
+			}
+			enc.Add(&secretNote{Title: "t", Body: "b"})
+			if err := enc.Commit(context.Background()); err != nil {
+				t.Errorf("Commit %d: %v", i, err)
+			}
+		})
+		wg.Go(func() {
+			c := uow.Clone()
+			c.Add(&secretNote{Title: "t"})
+			if err := c.Commit(context.Background()); err != nil {
+				t.Errorf("Commit: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,96 @@
+package tracker
+
+import (
+	"errors"
+
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when a lookup matches no rows. It is the same value GORM uses,
+// so errors from First and PreloadFirst match it as well.
+var ErrNotFound = gorm.ErrRecordNotFound
+
+// ErrNotOwned is returned by Close when the UnitOfWork does not own its connection.
+var ErrNotOwned = errors.New("tracker: unit of work does not own its database connection")
+
+// ErrNoActiveTransaction is returned by helpers that must run inside a transaction, such as
+// FindWithLock, when the UnitOfWork is not bound to one.
+var ErrNoActiveTransaction = errors.New("tracker: no active transaction")
+
+// ErrMaxDepthExceeded is returned by Commit when called again while the configured number of
+// commits (see WithMaxSaveChangesCallDepth) are already in progress on the same UnitOfWork.
+var ErrMaxDepthExceeded = errors.New("tracker: maximum SaveChanges call depth exceeded")
+
+// ErrSchemaDrift is returned by AssertSchemaMatchesModels when the database schema differs
+// from the model definitions.
+var ErrSchemaDrift = errors.New("tracker: database schema does not match models")
+
+// ErrPanicRecovered wraps a panic raised while a commit applied its work, when
+// WithPanicRecovery is enabled. The transaction is rolled back.
+var ErrPanicRecovered = errors.New("tracker: panic during commit")
+
+// ErrConflict is returned by Commit under WithOptimisticLock when an updated entity's row
+// no longer has the version the entity was loaded with. The transaction is rolled back.
+var ErrConflict = errors.New("tracker: optimistic lock conflict")
+
+// ErrImmutable is returned when trying to update or delete records of an append-only store
+// such as EventStoreUoW.
+var ErrImmutable = errors.New("tracker: records are immutable")
+
+// ErrTransactionOpen is returned by maintenance commands such as Analyze that cannot run
+// inside a transaction, when the UnitOfWork is bound to one.
+var ErrTransactionOpen = errors.New("tracker: cannot run inside a transaction")
+
+// ErrReadOnly is returned by Commit on a UnitOfWork created by AsReadOnly.
+var ErrReadOnly = errors.New("tracker: unit of work is read-only")
+
+// ErrEntityInSavepoint is returned by Commit when Add, Update or RegisterDelete was called
+// between CreateSavepoint and the matching RollbackSavepoint or ReleaseSavepoint. Entity
+// writes run before custom operations, so the savepoint would not cover them.
+var ErrEntityInSavepoint = errors.New("tracker: entity queued inside a savepoint block")
+
+// ErrSavepointInBatch is returned by BatchCommit when the queue holds statements of
+// CreateSavepoint, RollbackSavepoint or ReleaseSavepoint, which must run in one transaction.
+var ErrSavepointInBatch = errors.New("tracker: savepoint statements cannot be split across batches")
+
+// ErrUnsupportedDialect is returned by features that are not available on the database in use.
+var ErrUnsupportedDialect = errors.New("tracker: not supported by this database dialect")
+
+// sqlStateError is implemented by PostgreSQL drivers (pgx, lib/pq) to expose the SQLSTATE code.
+type sqlStateError interface {
+	SQLState() string
+}
+
+// sqlState returns the SQLSTATE code carried by err, or "" when the driver does not provide one.
+func sqlState(err error) string {
+	var se sqlStateError
+	if errors.As(err, &se) {
+		return se.SQLState()
+	}
+	return ""
+}
+
+// isSerializationFailure reports whether err is a transient conflict that retrying the
+// whole transaction may resolve: SQLITE_BUSY/SQLITE_LOCKED or PostgreSQL 40001/40P01.
+func isSerializationFailure(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+	switch sqlState(err) {
+	case "40001", "40P01":
+		return true
+	}
+	return false
+}
+
+// isUniqueViolation reports whether err is a unique-constraint conflict:
+// SQLITE_CONSTRAINT_UNIQUE or PostgreSQL 23505.
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+	}
+	return sqlState(err) == "23505"
+}
@@ -0,0 +1,99 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrEventPublishFailed is returned by Commit when the data was committed but publishing
+// the recorded domain events or OnCommitPublish messages failed. The publisher's error is
+// wrapped alongside it.
+var ErrEventPublishFailed = errors.New("tracker: changes committed but event publishing failed")
+
+// DomainEvent is something that happened in the domain and should be announced once the
+// changes that caused it are durable.
+type DomainEvent interface {
+	EventType() string
+	OccurredAt() time.Time
+}
+
+// EventPublisher delivers domain events, e.g. to a message queue.
+type EventPublisher interface {
+	Publish(ctx context.Context, events []DomainEvent) error
+}
+
+// WithEventPublisher sets the publisher that receives events recorded via RecordEvent
+// after each successful commit. Without a publisher, recorded events are discarded.
+func WithEventPublisher(p EventPublisher) Option {
+	return func(o *options) { o.publisher = p }
+}
+
+// PublishFunc sends a JSON payload to topic on a message bus such as NATS or Kafka.
+type PublishFunc func(ctx context.Context, topic string, payload []byte) error
+
+// WithPublisher sets the function OnCommitPublish messages are handed to.
+func WithPublisher(fn PublishFunc) Option {
+	return func(o *options) { o.publishFn = fn }
+}
+
+// message is a payload queued by OnCommitPublish.
+type message struct {
+	topic   string
+	payload []byte
+}
+
+// OnCommitPublish serializes payload to JSON now and publishes it to topic through the
+// WithPublisher function after the next successful commit, after the AfterCommit callbacks,
+// with the commit's context. If publishing fails, Commit returns ErrEventPublishFailed
+// wrapping the publisher's error, as for domain events. Without a publisher the message is
+// dropped. A payload that cannot be serialized fails the commit.
+func (r *UnitOfWork) OnCommitPublish(topic string, payload any) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		r.Do(func(Tx) error { return fmt.Errorf("tracker: publish %s: %w", topic, err) })
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, message{topic: topic, payload: data})
+}
+
+// RecordEvent queues a domain event to be published after the next successful commit.
+// Events are published in the order they were recorded.
+func (r *UnitOfWork) RecordEvent(event DomainEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// publishMessages hands the messages queued by OnCommitPublish to the WithPublisher
+// function, if any, in queue order.
+func (r *UnitOfWork) publishMessages(ctx context.Context, msgs []message) error {
+	if r.opts.publishFn == nil {
+		return nil
+	}
+	var errs []error
+	for _, m := range msgs {
+		if err := r.opts.publishFn(ctx, m.topic, m.payload); err != nil {
+			errs = append(errs, fmt.Errorf("publish %s: %w", m.topic, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("%w: %w", ErrEventPublishFailed, err)
+	}
+	return nil
+}
+
+// publishEvents hands events to the configured publisher, if any.
+func (r *UnitOfWork) publishEvents(ctx context.Context, events []DomainEvent) error {
+	if r.opts.publisher == nil || len(events) == 0 {
+		return nil
+	}
+	if err := r.opts.publisher.Publish(ctx, events); err != nil {
+		return fmt.Errorf("%w: %w", ErrEventPublishFailed, err)
+	}
+	return nil
+}
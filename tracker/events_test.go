@@ -0,0 +1,65 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type publishedOrder struct {
+	Item string
+	ID   uint
+}
+
+type publishCtxKey struct{}
+
+func TestOnCommitPublish(t *testing.T) {
+	errBus := errors.New("bus down")
+	tests := []struct {
+		publishErr error
+		wantErr    error
+		name       string
+	}{
+		{name: "published"},
+		{name: "publisher fails", publishErr: errBus, wantErr: ErrEventPublishFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			type call struct {
+				ctxValue  any
+				topic     string
+				payload   string
+				committed int64
+			}
+			var calls []call
+			var uow *UnitOfWork
+			uow = newTestUoW(t, []any{&publishedOrder{}}, WithPublisher(func(ctx context.Context, topic string, payload []byte) error {
+				n, err := uow.Count(context.Background(), &publishedOrder{})
+				if err != nil {
+					t.Errorf("Count: %v", err)
+				}
+				calls = append(calls, call{ctxValue: ctx.Value(publishCtxKey{}), topic: topic, payload: string(payload), committed: n})
+				return tt.publishErr
+			}))
+
+			uow.Add(&publishedOrder{Item: "book"})
+			uow.OnCommitPublish("orders.created", map[string]string{"item": "book"})
+			if len(calls) != 0 {
+				t.Fatal("published before Commit")
+			}
+			ctx := context.WithValue(context.Background(), publishCtxKey{}, "commit")
+			err := uow.Commit(ctx)
+			if !errors.Is(err, tt.wantErr) || (tt.publishErr != nil && !errors.Is(err, tt.publishErr)) {
+				t.Fatalf("Commit = %v, want %v", err, tt.wantErr)
+			}
+
+			want := call{ctxValue: "commit", topic: "orders.created", payload: `{"item":"book"}`, committed: 1}
+			if len(calls) != 1 || calls[0] != want {
+				t.Fatalf("publish calls = %+v, want [%+v]", calls, want)
+			}
+			if uow.HasPending() {
+				t.Fatal("queue not cleared after the commit")
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// appendAttempts is how many times Append retries when a concurrent append to the same
+// stream took its sequence numbers or locked the database.
+const appendAttempts = 50
+
+// StoredEvent is a row of the append-only event store.
+type StoredEvent struct {
+	OccurredAt time.Time `gorm:"not null"`
+	StreamID   string    `gorm:"size:255;not null;uniqueIndex:idx_stored_events_stream_seq,priority:1"`
+	Type       string    `gorm:"size:255;not null"`
+	// Payload is the JSON encoding of the appended event.
+	Payload []byte
+	ID      uint  `gorm:"primaryKey"`
+	Seq     int64 `gorm:"not null;uniqueIndex:idx_stored_events_stream_seq,priority:2"`
+}
+
+// EventStoreUoW is a UnitOfWork over an append-only event store: events are appended to
+// streams with Append and read back with Load, and stored events are never updated or
+// deleted. The wrapped UnitOfWork is not exposed, so its queue cannot be used to bypass
+// that.
+type EventStoreUoW struct {
+	uow        *UnitOfWork
+	migrate    sync.Once
+	migrateErr error
+}
+
+// EventStore wraps uow as an event store. The StoredEvent table is created on first use.
+func EventStore(uow *UnitOfWork) *EventStoreUoW {
+	return &EventStoreUoW{uow: uow}
+}
+
+// Update returns ErrImmutable; stored events cannot be changed.
+func (r *EventStoreUoW) Update(any) error { return ErrImmutable }
+
+// RegisterDelete returns ErrImmutable; stored events cannot be removed.
+func (r *EventStoreUoW) RegisterDelete(any) error { return ErrImmutable }
+
+// Append stores events at the end of stream streamID in one transaction, numbering them
+// from the stream's current MAX(seq)+1. Sequence numbers are unique per stream, so when a
+// concurrent Append takes them first the transaction is retried with the new maximum;
+// streams therefore have neither gaps nor duplicates.
+func (r *EventStoreUoW) Append(ctx context.Context, streamID string, events []DomainEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if err := r.ensureTable(ctx); err != nil {
+		return err
+	}
+	rows := make([]StoredEvent, len(events))
+	for i, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("tracker: append to %s: %w", streamID, err)
+		}
+		rows[i] = StoredEvent{StreamID: streamID, Type: e.EventType(), OccurredAt: e.OccurredAt(), Payload: payload}
+	}
+
+	s := r.uow.scoped(r.uow.mustRoot())
+	s.opts.isRetryable = func(err error) bool { return isUniqueViolation(err) || isSerializationFailure(err) }
+	return s.RunWithRetry(ctx, appendAttempts, func(uow *UnitOfWork) error {
+		uow.Do(func(tx Tx) error {
+			db := tx.(interface{ gormDB() *gorm.DB }).gormDB()
+			if db.Dialector.Name() == "sqlite" {
+				// Take the write lock before reading: SQLite cannot upgrade the read lock
+				// while another Append writes, and fails at once instead of waiting.
+				err := db.Model(&StoredEvent{}).Where("1 = 0").UpdateColumn("seq", gorm.Expr("seq")).Error
+				if err != nil {
+					return err
+				}
+			}
+			var last int64
+			err := db.Model(&StoredEvent{}).Where("stream_id = ?", streamID).
+				Select("COALESCE(MAX(seq), 0)").Scan(&last).Error
+			if err != nil {
+				return err
+			}
+			for i := range rows {
+				rows[i].ID = 0
+				rows[i].Seq = last + int64(i) + 1
+			}
+			return db.Create(&rows).Error
+		})
+		return nil
+	})
+}
+
+// Load returns the events of stream streamID in sequence order.
+func (r *EventStoreUoW) Load(ctx context.Context, streamID string) ([]StoredEvent, error) {
+	if err := r.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	var out []StoredEvent
+	err := r.uow.conn(ctx).Where("stream_id = ?", streamID).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: "seq"}}).Find(&out).Error
+	return out, err
+}
+
+// ensureTable creates the StoredEvent table once per EventStoreUoW.
+func (r *EventStoreUoW) ensureTable(ctx context.Context) error {
+	r.migrate.Do(func() { r.migrateErr = r.uow.conn(ctx).AutoMigrate(&StoredEvent{}) })
+	return r.migrateErr
+}
@@ -0,0 +1,90 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+type streamEvent struct {
+	At time.Time
+	N  int
+}
+
+func (e streamEvent) EventType() string     { return "stream.event" }
+func (e streamEvent) OccurredAt() time.Time { return e.At }
+
+func TestEventStoreAppendConcurrent(t *testing.T) {
+	ctx := context.Background()
+	store := EventStore(newTestUoW(t, nil))
+	streams := []string{"order-1", "order-2"}
+	const writers, perWriter = 4, 5
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(streams)*writers)
+	for _, stream := range streams {
+		for w := range writers {
+			wg.Go(func() {
+				events := make([]DomainEvent, perWriter)
+				for i := range events {
+					events[i] = streamEvent{N: w*perWriter + i, At: time.Now()}
+				}
+				errs <- store.Append(ctx, stream, events)
+			})
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	for _, stream := range streams {
+		events, err := store.Load(ctx, stream)
+		if err != nil {
+			t.Fatalf("Load(%s): %v", stream, err)
+		}
+		if len(events) != writers*perWriter {
+			t.Fatalf("%s has %d events, want %d", stream, len(events), writers*perWriter)
+		}
+		for i, e := range events {
+			if e.Seq != int64(i+1) || e.StreamID != stream {
+				t.Fatalf("%s event %d = seq %d stream %s, want seq %d", stream, i, e.Seq, e.StreamID, i+1)
+			}
+		}
+	}
+}
+
+func TestEventStoreImmutable(t *testing.T) {
+	store := EventStore(newTestUoW(t, nil))
+	tests := []struct {
+		call func() error
+		name string
+	}{
+		{name: "Update", call: func() error { return store.Update(&StoredEvent{ID: 1}) }},
+		{name: "RegisterDelete", call: func() error { return store.RegisterDelete(&StoredEvent{ID: 1}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, ErrImmutable) {
+				t.Fatalf("err = %v, want ErrImmutable", err)
+			}
+		})
+	}
+
+	// The wrapped UnitOfWork's methods, such as Do and Commit, must not be promoted.
+	var methods []string
+	typ := reflect.TypeFor[*EventStoreUoW]()
+	for i := range typ.NumMethod() {
+		methods = append(methods, typ.Method(i).Name)
+	}
+	if want := []string{"Append", "Load", "RegisterDelete", "Update"}; !slices.Equal(methods, want) {
+		t.Fatalf("methods = %v, want %v", methods, want)
+	}
+}
@@ -0,0 +1,51 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+type groupedItem struct {
+	Group string
+	Code  string `gorm:"uniqueIndex"`
+	ID    uint
+}
+
+func TestGroupCommit(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		dupGroup  string
+		wantFail  []string
+		wantSaved int64
+	}{
+		{name: "all groups commit", wantSaved: 20},
+		{name: "group B violates a constraint", dupGroup: "B", wantFail: []string{"B"}, wantSaved: 13}, // A has 7 items, C has 6,
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&groupedItem{}})
+			var entities []any
+			for i := range 20 {
+				item := &groupedItem{Group: []string{"A", "B", "C"}[i%3], Code: fmt.Sprint(i)}
+				if item.Group == tt.dupGroup {
+					item.Code = "dup"
+				}
+				entities = append(entities, item)
+			}
+			errs := GroupCommit(ctx, uow, entities, func(e any) string { return e.(*groupedItem).Group })
+			if len(errs) != len(tt.wantFail) {
+				t.Fatalf("errors = %v, want failures for %v", errs, tt.wantFail)
+			}
+			for _, g := range tt.wantFail {
+				if errs[g] == nil {
+					t.Fatalf("group %s committed, want a constraint error", g)
+				}
+			}
+			if n, err := uow.Count(ctx, &groupedItem{}); err != nil || n != tt.wantSaved {
+				t.Fatalf("Count = %d, %v, want %d", n, err, tt.wantSaved)
+			}
+		})
+	}
+}
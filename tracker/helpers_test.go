@@ -0,0 +1,39 @@
+package tracker
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newTestDB opens a fresh file-backed SQLite database that is closed with the test.
+func newTestDB(t testing.TB) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "test.db")+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// newTestUoW returns a UnitOfWork on a fresh database with models migrated.
+func newTestUoW(t testing.TB, models []any, opts ...Option) *UnitOfWork {
+	t.Helper()
+	uow, err := NewWithOptions(newTestDB(t), append(opts, WithAutoMigrate(models...))...)
+	if err != nil {
+		t.Fatalf("NewWithOptions: %v", err)
+	}
+	return uow
+}
+
+// mustCommit commits uow, failing the test on error.
+func mustCommit(t testing.TB, uow *UnitOfWork) {
+	t.Helper()
+	if err := uow.Commit(context.Background()); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+}
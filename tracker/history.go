@@ -0,0 +1,101 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// historyHooks records the GORM callback registries and tables history hooks were
+// installed on, so enabling history again does not copy each row twice.
+var historyHooks sync.Map
+
+// EnableHistoryTable keeps a full row history for model's table in <table>_history, which
+// has the same columns plus changed_at and is created if missing. Every inserted or
+// updated row is copied there after the write, and every deleted row right before it.
+//
+// On PostgreSQL this is a database trigger, so it sees every write. On SQLite it is a GORM
+// hook on the shared root (see HookOnTable), so it only sees writes GORM issues with the
+// affected entities, e.g. those of a UnitOfWork commit, and not raw SQL or deletes by
+// condition. Other dialects return ErrUnsupportedDialect.
+func EnableHistoryTable(ctx context.Context, uow *UnitOfWork, model any) error {
+	db := uow.conn(ctx)
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	sch := stmt.Schema
+	if sch.PrioritizedPrimaryField == nil {
+		return fmt.Errorf("tracker: history for %s: model has no primary key", sch.Name)
+	}
+	dialect := db.Dialector.Name()
+	if dialect != "postgres" && dialect != "sqlite" {
+		return fmt.Errorf("tracker: history for %s on %s: %w", sch.Name, dialect, ErrUnsupportedDialect)
+	}
+
+	table, history := sch.Table, sch.Table+"_history"
+	if !db.Migrator().HasTable(history) {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("CREATE TABLE ? AS SELECT * FROM ? WHERE 1 = 0",
+				clause.Table{Name: history}, clause.Table{Name: table}).Error; err != nil {
+				return err
+			}
+			return tx.Exec("ALTER TABLE ? ADD COLUMN changed_at timestamp", clause.Table{Name: history}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("tracker: create %s: %w", history, err)
+		}
+	}
+
+	if dialect == "postgres" {
+		return historyTrigger(db, table, history)
+	}
+	root := uow.mustRoot()
+	key := historyKey{callbacks: root.Callback(), table: table}
+	if _, done := historyHooks.LoadOrStore(key, true); done {
+		return nil
+	}
+	pk := sch.PrioritizedPrimaryField.DBName
+	copyRow := func(tx Tx, id any) error {
+		return tx.Exec("INSERT INTO ? SELECT *, CURRENT_TIMESTAMP FROM ? WHERE ? = ?",
+			clause.Table{Name: history}, clause.Table{Name: table}, clause.Column{Name: pk}, id)
+	}
+	if _, err := addTableHook(root, table, OnCreate|OnUpdate|onBeforeDelete, copyRow); err != nil {
+		historyHooks.Delete(key) // let a later call install the hook
+		return err
+	}
+	return nil
+}
+
+// historyKey identifies a table on one GORM callback registry.
+type historyKey struct {
+	callbacks any
+	table     string
+}
+
+// historyTrigger installs the PostgreSQL trigger that copies table's rows into history.
+func historyTrigger(db *gorm.DB, table, history string) error {
+	fn := db.Statement.Quote(history + "_fn")
+	sql := fmt.Sprintf(`
+CREATE OR REPLACE FUNCTION %[1]s() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		INSERT INTO %[2]s SELECT OLD.*, now();
+		RETURN OLD;
+	END IF;
+	INSERT INTO %[2]s SELECT NEW.*, now();
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS %[3]s ON %[4]s;
+CREATE TRIGGER %[3]s AFTER INSERT OR UPDATE OR DELETE ON %[4]s
+	FOR EACH ROW EXECUTE FUNCTION %[1]s();`,
+		fn, db.Statement.Quote(history), db.Statement.Quote(history+"_trigger"), db.Statement.Quote(table))
+	if err := db.Exec(sql).Error; err != nil {
+		return fmt.Errorf("tracker: history trigger on %s: %w", table, err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package tracker
+
+import (
+	"context"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type auditedRow struct {
+	Name string
+	ID   uint
+}
+
+func TestEnableHistoryTable(t *testing.T) {
+	ctx := context.Background()
+	uow := newTestUoW(t, []any{&auditedRow{}})
+	for range 2 { // enabling twice must not copy rows twice
+		if err := EnableHistoryTable(ctx, uow, &auditedRow{}); err != nil {
+			t.Fatalf("EnableHistoryTable: %v", err)
+		}
+	}
+	row := &auditedRow{Name: "a"}
+	uow.Add(row)
+	mustCommit(t, uow)
+	row.Name = "b"
+	uow.Update(row)
+	mustCommit(t, uow)
+	uow.RegisterDelete(row)
+	mustCommit(t, uow)
+
+	var names []string
+	if err := uow.mustRoot().Table("audited_rows_history").Order("rowid").Pluck("name", &names).Error; err != nil {
+		t.Fatalf("read history: %v", err)
+	}
+	want := []string{"a", "b", "b"}
+	if len(names) != len(want) {
+		t.Fatalf("history = %q, want %q", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("history = %q, want %q", names, want)
+		}
+	}
+}
+
+func TestEnableHistoryTableRetriesFailedHook(t *testing.T) {
+	ctx := context.Background()
+	uow := newTestUoW(t, []any{&auditedRow{}})
+	// Make installing the table hooks fail with a callback that must run both before
+	// gorm:create and after the tracker's create hook.
+	cb := uow.mustRoot().Callback()
+	tableHookSets.Delete(cb)
+	_ = cb.Create().Before("gorm:create").After("tracker:table_hooks").Register("test:conflict", func(*gorm.DB) {})
+	if err := EnableHistoryTable(ctx, uow, &auditedRow{}); err == nil {
+		t.Fatal("EnableHistoryTable succeeded with conflicting callbacks")
+	}
+
+	if err := cb.Create().Remove("test:conflict"); err != nil {
+		t.Fatalf("remove conflict: %v", err)
+	}
+	if err := EnableHistoryTable(ctx, uow, &auditedRow{}); err != nil {
+		t.Fatalf("EnableHistoryTable: %v", err)
+	}
+	uow.Add(&auditedRow{Name: "a"})
+	mustCommit(t, uow)
+	var n int64
+	if err := uow.mustRoot().Table("audited_rows_history").Count(&n).Error; err != nil || n != 1 {
+		t.Fatalf("history rows = %d, %v, want 1", n, err)
+	}
+}
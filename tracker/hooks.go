@@ -0,0 +1,158 @@
+package tracker
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// HookEvent selects the writes a table hook reacts to. Events can be combined with |.
+type HookEvent int
+
+// Table hook events.
+const (
+	OnCreate HookEvent = 1 << iota
+	OnUpdate
+	OnDelete
+)
+
+// onBeforeDelete is the internal event fired right before rows are deleted, used by
+// EnableHistoryTable.
+const onBeforeDelete HookEvent = OnDelete << 1
+
+// hookSeq numbers table hook registrations, so they can be removed.
+var hookSeq atomic.Uint64
+
+// tableHookSets maps GORM callback registries to the table hooks dispatched on them.
+var tableHookSets sync.Map
+
+// tableHookSet holds the table hooks of one GORM callback registry. The GORM callbacks that
+// dispatch to them are registered once, when the root is opened, so adding and removing
+// hooks never changes the registry while other goroutines use it.
+type tableHookSet struct {
+	hooks map[tableHookKey][]tableHookEntry
+	mu    sync.RWMutex
+}
+
+// tableHookKey selects the hooks of one table and event.
+type tableHookKey struct {
+	table string
+	event HookEvent
+}
+
+// tableHookEntry is one registered hook.
+type tableHookEntry struct {
+	fn  func(tx Tx, id any) error
+	seq uint64
+}
+
+// HookOnTable runs fn for every row written to table, whichever code path issued the write.
+// It applies to every UnitOfWork built on the same *sql.DB and GORM configuration, and
+// fires inside the writing transaction: returning an error rolls that transaction back. id
+// is the primary key of the affected row.
+//
+// Hooks stay installed until the returned remove function is called, so register them
+// once at startup, or remove them when the UoW that added them is done; hooks added per
+// request without being removed pile up and fire once each.
+func (r *UnitOfWork) HookOnTable(table string, event HookEvent, fn func(tx Tx, id any) error) (remove func(), err error) {
+	return addTableHook(r.mustRoot(), table, event, fn)
+}
+
+// installTableHooks registers the GORM callbacks dispatching to table hooks on db's
+// registry, once.
+func installTableHooks(db *gorm.DB) error {
+	set := &tableHookSet{hooks: map[tableHookKey][]tableHookEntry{}}
+	if _, loaded := tableHookSets.LoadOrStore(db.Callback(), set); loaded {
+		return nil
+	}
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().After("gorm:create").Register("tracker:table_hooks", set.dispatch(OnCreate)),
+		cb.Update().After("gorm:update").Register("tracker:table_hooks", set.dispatch(OnUpdate)),
+		cb.Delete().After("gorm:delete").Register("tracker:table_hooks", set.dispatch(OnDelete)),
+		cb.Delete().Before("gorm:delete").Register("tracker:table_hooks_before", set.dispatch(onBeforeDelete)),
+	)
+}
+
+// addTableHook adds fn for each event in event on table, returning the function removing
+// it again.
+func addTableHook(db *gorm.DB, table string, event HookEvent, fn func(tx Tx, id any) error) (func(), error) {
+	if err := installTableHooks(db); err != nil {
+		return nil, err
+	}
+	v, _ := tableHookSets.Load(db.Callback())
+	set := v.(*tableHookSet)
+	entry := tableHookEntry{fn: fn, seq: hookSeq.Add(1)}
+	var keys []tableHookKey
+	for e := OnCreate; e <= onBeforeDelete; e <<= 1 {
+		if event&e != 0 {
+			keys = append(keys, tableHookKey{table: table, event: e})
+		}
+	}
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	for _, k := range keys {
+		set.hooks[k] = append(slices.Clip(set.hooks[k]), entry)
+	}
+	return func() {
+		set.mu.Lock()
+		defer set.mu.Unlock()
+		for _, k := range keys {
+			set.hooks[k] = slices.DeleteFunc(slices.Clone(set.hooks[k]), func(e tableHookEntry) bool { return e.seq == entry.seq })
+		}
+	}, nil
+}
+
+// dispatch returns the GORM callback running the hooks of event for the statement's table.
+func (s *tableHookSet) dispatch(event HookEvent) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil {
+			return
+		}
+		s.mu.RLock()
+		hooks := s.hooks[tableHookKey{table: db.Statement.Table, event: event}]
+		s.mu.RUnlock()
+		if len(hooks) == 0 {
+			return
+		}
+		tx := gormTx{db: db.Session(&gorm.Session{NewDB: true})}
+		ids := primaryKeys(db)
+		for _, h := range hooks {
+			for _, id := range ids {
+				if err := h.fn(tx, id); err != nil {
+					_ = db.AddError(err)
+					return
+				}
+			}
+		}
+	}
+}
+
+// primaryKeys returns the non-zero primary key values of the rows held by the statement.
+func primaryKeys(db *gorm.DB) []any {
+	stmt := db.Statement
+	if stmt.Schema == nil || stmt.Schema.PrioritizedPrimaryField == nil {
+		return nil
+	}
+	field := stmt.Schema.PrioritizedPrimaryField
+	var ids []any
+	collect := func(rv reflect.Value) {
+		if id, zero := field.ValueOf(stmt.Context, rv); !zero {
+			ids = append(ids, id)
+		}
+	}
+	switch rv := reflect.Indirect(stmt.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := range rv.Len() {
+			collect(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		collect(rv)
+	default:
+	}
+	return ids
+}
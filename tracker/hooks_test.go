@@ -0,0 +1,102 @@
+package tracker
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+type hookedRow struct {
+	Name string
+	ID   uint
+}
+
+func TestHookOnTableEvents(t *testing.T) {
+	tests := []struct {
+		name  string
+		event HookEvent
+		want  int
+	}{
+		{name: "create", event: OnCreate, want: 1},
+		{name: "update", event: OnUpdate, want: 1},
+		{name: "delete", event: OnDelete, want: 1},
+		{name: "all", event: OnCreate | OnUpdate | OnDelete, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&hookedRow{}})
+			fired := 0
+			remove, err := uow.HookOnTable("hooked_rows", tt.event, func(Tx, any) error {
+				fired++
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("HookOnTable: %v", err)
+			}
+			defer remove()
+			row := &hookedRow{Name: "a"}
+			uow.Add(row)
+			mustCommit(t, uow)
+			row.Name = "b"
+			uow.Update(row)
+			mustCommit(t, uow)
+			uow.RegisterDelete(row)
+			mustCommit(t, uow)
+			if fired != tt.want {
+				t.Fatalf("fired %d times, want %d", fired, tt.want)
+			}
+		})
+	}
+}
+
+func TestHookOnTableRemove(t *testing.T) {
+	uow := newTestUoW(t, []any{&hookedRow{}})
+	fired := 0
+	for range 3 {
+		remove, err := uow.Clone().HookOnTable("hooked_rows", OnCreate, func(Tx, any) error {
+			fired++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("HookOnTable: %v", err)
+		}
+		remove()
+	}
+	remove, err := uow.HookOnTable("hooked_rows", OnCreate, func(Tx, any) error {
+		fired++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("HookOnTable: %v", err)
+	}
+	defer remove()
+	uow.Add(&hookedRow{Name: "a"})
+	mustCommit(t, uow)
+	if fired != 1 {
+		t.Fatalf("fired %d times, want 1", fired)
+	}
+}
+
+func TestHookOnTableConcurrentRegistration(t *testing.T) {
+	ctx := context.Background()
+	uow := newTestUoW(t, []any{&hookedRow{}})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for range 20 {
+				remove, err := uow.HookOnTable("hooked_rows", OnCreate, func(Tx, any) error { return nil })
+				if err != nil {
+					t.Errorf("HookOnTable: %v", err)
+					return
+				}
+				w := uow.Clone()
+				w.Add(&hookedRow{Name: "x"})
+				if err := w.Commit(ctx); err != nil {
+					t.Errorf("Commit: %v", err)
+				}
+				remove()
+			}
+		})
+	}
+	wg.Wait()
+}
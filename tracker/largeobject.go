@@ -0,0 +1,120 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// largeObjectChunk is how many bytes each lo_put/lo_get round trip transfers.
+const largeObjectChunk = 1 << 20
+
+// LargeObjectCreate stores everything read from src as a new PostgreSQL large object and
+// returns its OID. The object is created and written in a single transaction, so a failed
+// read or write leaves nothing behind. Other dialects fail with ErrUnsupportedDialect.
+func LargeObjectCreate(ctx context.Context, uow *UnitOfWork, src io.Reader) (uint32, error) {
+	var oid uint32
+	err := largeObjectTx(ctx, uow, "LargeObjectCreate", func(tx *gorm.DB) error {
+		if err := tx.Raw("SELECT lo_create(0)").Row().Scan(&oid); err != nil {
+			return err
+		}
+		buf := make([]byte, largeObjectChunk)
+		for off := int64(0); ; {
+			n, err := io.ReadFull(src, buf)
+			if n > 0 {
+				if err := tx.Exec("SELECT lo_put(?, ?, ?)", oid, off, buf[:n]).Error; err != nil {
+					return err
+				}
+				off += int64(n)
+			}
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	return oid, nil
+}
+
+// LargeObjectRead opens the PostgreSQL large object oid for streaming. Reads happen inside a
+// transaction that stays open until the returned ReadCloser is closed, so callers must
+// always Close it. Other dialects fail with ErrUnsupportedDialect.
+func LargeObjectRead(ctx context.Context, uow *UnitOfWork, oid uint32) (io.ReadCloser, error) {
+	db := uow.conn(ctx)
+	if name := db.Dialector.Name(); name != "postgres" {
+		return nil, fmt.Errorf("%w: LargeObjectRead on %s", ErrUnsupportedDialect, name)
+	}
+	tx := db.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	// Fail fast on unknown OIDs instead of on the first Read.
+	if err := tx.Exec("SELECT lo_get(?, 0, 0)", oid).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return &largeObjectReader{tx: tx, oid: oid}, nil
+}
+
+// LargeObjectDelete removes the PostgreSQL large object oid.
+// Other dialects fail with ErrUnsupportedDialect.
+func LargeObjectDelete(ctx context.Context, uow *UnitOfWork, oid uint32) error {
+	return largeObjectTx(ctx, uow, "LargeObjectDelete", func(tx *gorm.DB) error {
+		return tx.Exec("SELECT lo_unlink(?)", oid).Error
+	})
+}
+
+// largeObjectTx runs fn in a transaction after checking the dialect supports large objects.
+func largeObjectTx(ctx context.Context, uow *UnitOfWork, feature string, fn func(tx *gorm.DB) error) error {
+	db := uow.conn(ctx)
+	if name := db.Dialector.Name(); name != "postgres" {
+		return fmt.Errorf("%w: %s on %s", ErrUnsupportedDialect, feature, name)
+	}
+	return db.Transaction(fn)
+}
+
+// largeObjectReader streams a large object in chunks fetched with lo_get.
+type largeObjectReader struct {
+	tx   *gorm.DB
+	oid  uint32
+	off  int64
+	buf  []byte
+	eof  bool
+	once sync.Once
+}
+
+// Read implements io.Reader.
+func (r *largeObjectReader) Read(p []byte) (int, error) {
+	if len(r.buf) == 0 {
+		if r.eof {
+			return 0, io.EOF
+		}
+		if err := r.tx.Raw("SELECT lo_get(?, ?, ?)", r.oid, r.off, largeObjectChunk).Row().Scan(&r.buf); err != nil {
+			return 0, err
+		}
+		r.off += int64(len(r.buf))
+		r.eof = len(r.buf) < largeObjectChunk
+		if len(r.buf) == 0 {
+			return 0, io.EOF
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// Close ends the read transaction. It is safe to call more than once.
+func (r *largeObjectReader) Close() error {
+	var err error
+	r.once.Do(func() { err = r.tx.Rollback().Error })
+	return err
+}
@@ -0,0 +1,163 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqlLogger is a GORM logger that reports every executed statement to the registered
+// callbacks before delegating to the wrapped logger.
+type sqlLogger struct {
+	logger.Interface
+	explain func(sql string, vars ...any) string
+	fns     []func(sql string, vars []any, d time.Duration)
+
+	// vars is filled by ParamsFilter while Trace holds mu.
+	mu   *sync.Mutex
+	vars []any
+}
+
+// withSQLHooks returns db with a logger feeding fns, or db itself when there are none.
+func withSQLHooks(db *gorm.DB, fns []func(sql string, vars []any, d time.Duration)) *gorm.DB {
+	if db == nil || len(fns) == 0 {
+		return db
+	}
+	return db.Session(&gorm.Session{Logger: &sqlLogger{
+		Interface: db.Logger,
+		explain:   db.Dialector.Explain,
+		fns:       fns,
+		mu:        &sync.Mutex{},
+	}})
+}
+
+// LogMode keeps the callbacks while changing the wrapped logger's level.
+func (l *sqlLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return &sqlLogger{Interface: l.Interface.LogMode(level), explain: l.explain, fns: l.fns, mu: &sync.Mutex{}}
+}
+
+// ParamsFilter holds the bound values back from GORM's explain step, so Trace can report
+// the placeholder SQL and its vars separately.
+func (l *sqlLogger) ParamsFilter(_ context.Context, sql string, params ...any) (string, []any) {
+	l.vars = params
+	return sql, nil
+}
+
+// Trace reports the statement to the callbacks and forwards it, with values inlined,
+// to the wrapped logger.
+func (l *sqlLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	l.mu.Lock()
+	sql, rows := fc()
+	vars := l.vars
+	l.vars = nil
+	l.mu.Unlock()
+
+	elapsed := time.Since(begin)
+	for _, fn := range l.fns {
+		fn(sql, vars, elapsed)
+	}
+	l.Interface.Trace(ctx, begin, func() (string, int64) { return l.explain(sql, vars...), rows }, err)
+}
+
+// LogOperations returns a scoped UnitOfWork whose commits write their plan to w before
+// sending any SQL: one line per pending item in execution order, e.g.
+//
+//	CREATE Customer(Name=Ada, Email=ada@example.com)
+//	DELETE Order(ID=7)
+//
+// Entities list their non-zero fields; custom operations are written as DO.
+func (r *UnitOfWork) LogOperations(w io.Writer) *UnitOfWork {
+	s := r.scoped(r.mustRoot())
+	s.opts.planWriter = w
+	return s
+}
+
+// PrecomputeSQL returns the statements the next Commit would execute, in order and with
+// their placeholders, without executing anything: the pending work is applied to a GORM
+// DryRun session. The BEGIN and COMMIT around them are not included. Results are only as
+// accurate as the queued work is deterministic: in a dry run queries return no rows and
+// inserted rows get no generated keys, so custom operations that depend on either may
+// produce different statements on the real commit. WithBeforeSave and WithAfterSave hooks
+// run as they would on commit.
+func (r *UnitOfWork) PrecomputeSQL(ctx context.Context) ([]string, error) {
+	c := r.snapshot()
+	return dryRun(r.conn(ctx), func(tx *gorm.DB) error { return r.apply(tx, c) })
+}
+
+// ExplainOp returns the SQL op would execute, its statements separated by ";\n", by running
+// it against a GORM DryRun session; the database is not modified. As with PrecomputeSQL,
+// queries inside op return no rows.
+func ExplainOp(ctx context.Context, uow *UnitOfWork, op Operation) (string, error) {
+	stmts, err := dryRun(uow.conn(ctx), func(tx *gorm.DB) error { return op(gormTx{db: tx}) })
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(stmts, ";\n"), nil
+}
+
+// dryRun calls fn with a DryRun session of db and returns the statements it built.
+func dryRun(db *gorm.DB, fn func(tx *gorm.DB) error) ([]string, error) {
+	var out []string
+	capture := func(sql string, _ []any, _ time.Duration) { out = append(out, sql) }
+	dry := db.Session(&gorm.Session{DryRun: true, Logger: logger.Discard})
+	if err := fn(withSQLHooks(dry, []func(string, []any, time.Duration){capture})); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// writePlan writes c's pending items to the LogOperations writer, if any.
+func (r *UnitOfWork) writePlan(c changes) error {
+	if r.opts.planWriter == nil {
+		return nil
+	}
+	var b strings.Builder
+	for _, op := range c.pendingOps() {
+		if op.Kind == Custom {
+			b.WriteString("DO\n")
+			continue
+		}
+		for _, rv := range structValues(reflect.ValueOf(entityOf(op.Entity))) {
+			fmt.Fprintf(&b, "%s %s(%s)\n", strings.ToUpper(op.Kind.String()), rv.Type().Name(), planFields(rv))
+		}
+	}
+	_, err := io.WriteString(r.opts.planWriter, b.String())
+	return err
+}
+
+// planFields formats the non-zero exported fields of a struct as Name=value, flattening
+// embedded structs such as gorm.Model. Strings are written as is, other values as JSON.
+func planFields(rv reflect.Value) string {
+	var parts []string
+	for i := range rv.NumField() {
+		sf, v := rv.Type().Field(i), rv.Field(i)
+		if !sf.IsExported() || v.IsZero() {
+			continue
+		}
+		if sf.Anonymous && v.Kind() == reflect.Struct {
+			if inner := planFields(v); inner != "" {
+				parts = append(parts, inner)
+			}
+			continue
+		}
+		s, ok := v.Interface().(string)
+		if !ok {
+			data, err := json.Marshal(v.Interface())
+			if err != nil {
+				s = fmt.Sprintf("%v", v.Interface())
+			} else {
+				s = string(data)
+			}
+		}
+		parts = append(parts, sf.Name+"="+s)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,192 @@
+package tracker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Analyze refreshes the query planner statistics of tableName, e.g. after bulk updates or
+// deletes: VACUUM ANALYZE on PostgreSQL, which also reclaims dead rows, and ANALYZE on
+// SQLite. It returns ErrTransactionOpen when the UnitOfWork is bound to a transaction, since
+// PostgreSQL cannot vacuum inside one.
+func (r *UnitOfWork) Analyze(ctx context.Context, tableName string) error {
+	db := r.conn(ctx)
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); inTx {
+		return ErrTransactionOpen
+	}
+	var stmt string
+	switch name := db.Dialector.Name(); name {
+	case "postgres":
+		stmt = "VACUUM ANALYZE ?"
+	case "sqlite":
+		stmt = "ANALYZE ?"
+	default:
+		return fmt.Errorf("tracker: analyze on %s: %w", name, ErrUnsupportedDialect)
+	}
+	if err := db.Exec(stmt, clause.Table{Name: tableName}).Error; err != nil {
+		return fmt.Errorf("tracker: analyze %s: %w", tableName, err)
+	}
+	return nil
+}
+
+// TableSize returns the on-disk size in bytes of tableName, its indexes included:
+// pg_total_relation_size on PostgreSQL, and the pages listed by the dbstat virtual table on
+// SQLite. dbstat is only present when SQLite was compiled with SQLITE_ENABLE_DBSTAT_VTAB
+// (for mattn/go-sqlite3, CGO_CFLAGS=-DSQLITE_ENABLE_DBSTAT_VTAB); without it TableSize
+// fails with an error wrapping ErrUnsupportedDialect.
+func (r *UnitOfWork) TableSize(ctx context.Context, tableName string) (int64, error) {
+	db := r.conn(ctx)
+	var size sql.NullInt64
+	var err error
+	switch name := db.Dialector.Name(); name {
+	case "postgres":
+		err = db.Raw("SELECT pg_total_relation_size(CAST(? AS regclass))", tableName).Scan(&size).Error
+	case "sqlite":
+		err = db.Raw(`SELECT SUM(pgsize) FROM dbstat WHERE name = ?
+			OR name IN (SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ?)`,
+			tableName, tableName).Scan(&size).Error
+		if err != nil && strings.Contains(err.Error(), "no such table: dbstat") {
+			err = fmt.Errorf("dbstat unavailable: %w", ErrUnsupportedDialect)
+		}
+	default:
+		err = ErrUnsupportedDialect
+	}
+	if err != nil {
+		return 0, fmt.Errorf("tracker: size of %s: %w", tableName, err)
+	}
+	if !size.Valid {
+		return 0, fmt.Errorf("tracker: size of %s: no such table", tableName)
+	}
+	return size.Int64, nil
+}
+
+// IndexStat is the usage of one index as counted by the database since its statistics
+// were last reset.
+type IndexStat struct {
+	IndexName string
+	// Scans is how many index scans were started; an index that stays at zero is unused.
+	Scans int64
+	// TuplesRead counts index entries returned by scans, TuplesFetched the table rows
+	// fetched through them.
+	TuplesRead    int64
+	TuplesFetched int64
+}
+
+// IndexUsageStats returns the usage counters of tableName's indexes, ordered by name. On
+// PostgreSQL they come from pg_stat_user_indexes. SQLite keeps no such counters, so there
+// they are approximated from the SELECTs this UnitOfWork and its scopes have run: each is
+// passed to EXPLAIN QUERY PLAN, and every index its plan uses gets one scan per execution.
+// TuplesRead and TuplesFetched stay zero on SQLite, and queries run elsewhere, or beyond
+// the first maxLoggedQueries distinct statements, are not counted. Other dialects return
+// ErrUnsupportedDialect.
+func (r *UnitOfWork) IndexUsageStats(ctx context.Context, tableName string) ([]IndexStat, error) {
+	db := r.conn(ctx)
+	var out []IndexStat
+	var err error
+	switch name := db.Dialector.Name(); name {
+	case "postgres":
+		err = db.Raw(`SELECT indexrelname AS index_name, idx_scan AS scans,
+			idx_tup_read AS tuples_read, idx_tup_fetch AS tuples_fetched
+			FROM pg_stat_user_indexes WHERE relname = ? ORDER BY indexrelname`, tableName).Scan(&out).Error
+	case "sqlite":
+		out, err = r.sqliteIndexUsage(db, tableName)
+	default:
+		return nil, fmt.Errorf("tracker: index usage of %s on %s: %w", tableName, name, ErrUnsupportedDialect)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tracker: index usage of %s: %w", tableName, err)
+	}
+	return out, nil
+}
+
+// sqliteIndexUsage approximates IndexUsageStats on SQLite from the query log.
+func (r *UnitOfWork) sqliteIndexUsage(db *gorm.DB, tableName string) ([]IndexStat, error) {
+	var names []string
+	err := db.Raw("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ? ORDER BY name",
+		tableName).Scan(&names).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make([]IndexStat, len(names))
+	byName := make(map[string]*IndexStat, len(names))
+	for i, n := range names {
+		out[i].IndexName = n
+		byName[n] = &out[i]
+	}
+	for _, q := range r.opts.queries.snapshot() {
+		var plan []struct{ Detail string }
+		// Statements that no longer plan, e.g. on a dropped table, are skipped.
+		if db.Raw("EXPLAIN QUERY PLAN "+q.sql, q.vars...).Scan(&plan).Error != nil {
+			continue
+		}
+		for _, step := range plan {
+			if m := planIndex.FindStringSubmatch(step.Detail); m != nil && byName[m[1]] != nil {
+				byName[m[1]].Scans += q.count
+			}
+		}
+	}
+	return out, nil
+}
+
+// planIndex matches the index named in an EXPLAIN QUERY PLAN step.
+var planIndex = regexp.MustCompile(`USING (?:COVERING )?INDEX (\S+)`)
+
+// maxLoggedQueries bounds the distinct statements a queryLog keeps.
+const maxLoggedQueries = 256
+
+// queryLog counts the executions of distinct SELECT statements, for IndexUsageStats.
+type queryLog struct {
+	mu      sync.Mutex
+	queries map[string]*loggedQuery
+}
+
+// loggedQuery is a statement of a queryLog with the vars of its latest execution.
+type loggedQuery struct {
+	sql   string
+	vars  []any
+	count int64
+}
+
+// observe is registered as a SQL hook.
+func (l *queryLog) observe(sql string, vars []any, _ time.Duration) {
+	if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(sql)), "SELECT") {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	q, ok := l.queries[sql]
+	if !ok {
+		if len(l.queries) >= maxLoggedQueries {
+			return
+		}
+		if l.queries == nil {
+			l.queries = map[string]*loggedQuery{}
+		}
+		q = &loggedQuery{sql: sql}
+		l.queries[sql] = q
+	}
+	q.vars = vars
+	q.count++
+}
+
+// snapshot returns a copy of the logged queries. l may be nil.
+func (l *queryLog) snapshot() []loggedQuery {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]loggedQuery, 0, len(l.queries))
+	for _, q := range l.queries {
+		out = append(out, *q)
+	}
+	return out
+}
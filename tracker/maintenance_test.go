@@ -0,0 +1,118 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type sizedOrder struct {
+	Note string
+	ID   uint
+}
+
+// skipWithoutDbstat skips t when SQLite was built without the dbstat virtual table.
+func skipWithoutDbstat(t *testing.T, uow *UnitOfWork) {
+	t.Helper()
+	if _, err := uow.TableSize(context.Background(), "sized_orders"); errors.Is(err, ErrUnsupportedDialect) {
+		t.Skip("SQLite built without SQLITE_ENABLE_DBSTAT_VTAB")
+	}
+}
+
+func TestTableSize(t *testing.T) {
+	ctx := context.Background()
+	uow := newTestUoW(t, []any{&sizedOrder{}})
+	skipWithoutDbstat(t, uow)
+	empty, err := uow.TableSize(ctx, "sized_orders")
+	if err != nil {
+		t.Fatalf("TableSize: %v", err)
+	}
+
+	rows := make([]map[string]any, 10000)
+	for i := range rows {
+		rows[i] = map[string]any{"note": strings.Repeat("x", 64)}
+	}
+	if _, err := MultiInsert(ctx, uow, "sized_orders", rows); err != nil {
+		t.Fatalf("MultiInsert: %v", err)
+	}
+	full, err := uow.TableSize(ctx, "sized_orders")
+	if err != nil {
+		t.Fatalf("TableSize: %v", err)
+	}
+	if full <= 0 || full <= empty {
+		t.Fatalf("TableSize = %d after inserts, want > %d", full, empty)
+	}
+
+	if err := uow.mustRoot().Exec("DELETE FROM sized_orders").Error; err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := uow.mustRoot().Exec("VACUUM").Error; err != nil {
+		t.Fatalf("VACUUM: %v", err)
+	}
+	compacted, err := uow.TableSize(ctx, "sized_orders")
+	if err != nil {
+		t.Fatalf("TableSize: %v", err)
+	}
+	if compacted > full {
+		t.Fatalf("TableSize = %d after VACUUM, want <= %d", compacted, full)
+	}
+}
+
+func TestTableSizeMissingTable(t *testing.T) {
+	uow := newTestUoW(t, []any{&sizedOrder{}})
+	skipWithoutDbstat(t, uow)
+	if _, err := uow.TableSize(context.Background(), "missing"); err == nil || !strings.Contains(err.Error(), "no such table") {
+		t.Fatalf("TableSize = %v, want a no such table error", err)
+	}
+}
+
+func TestTableSizeWithoutDbstat(t *testing.T) {
+	uow := newTestUoW(t, []any{&sizedOrder{}})
+	if err := uow.mustRoot().Exec("SELECT 1 FROM dbstat LIMIT 1").Error; err == nil {
+		t.Skip("SQLite built with SQLITE_ENABLE_DBSTAT_VTAB")
+	}
+	if _, err := uow.TableSize(context.Background(), "sized_orders"); !errors.Is(err, ErrUnsupportedDialect) {
+		t.Fatalf("TableSize = %v, want ErrUnsupportedDialect", err)
+	}
+}
+
+type indexedOrder struct {
+	Status   string `gorm:"index"`
+	Customer string `gorm:"index"`
+	Note     string
+	ID       uint
+}
+
+func TestIndexUsageStats(t *testing.T) {
+	ctx := context.Background()
+	uow := newTestUoW(t, []any{&indexedOrder{}})
+	uow.Add(&indexedOrder{Status: "NEW", Customer: "ada"})
+	mustCommit(t, uow)
+
+	scoped := uow.Clone()
+	for range 3 {
+		var out []indexedOrder
+		if err := scoped.FindAll(ctx, &out, Where("status", "=", "NEW")); err != nil {
+			t.Fatalf("FindAll: %v", err)
+		}
+	}
+	var out []indexedOrder
+	if err := uow.FindAll(ctx, &out, Where("note", "=", "x")); err != nil {
+		t.Fatalf("FindAll: %v", err)
+	}
+
+	stats, err := uow.IndexUsageStats(ctx, "indexed_orders")
+	if err != nil {
+		t.Fatalf("IndexUsageStats: %v", err)
+	}
+	want := map[string]int64{"idx_indexed_orders_customer": 0, "idx_indexed_orders_status": 3}
+	if len(stats) != len(want) {
+		t.Fatalf("stats = %+v, want %v", stats, want)
+	}
+	for _, st := range stats {
+		if scans, ok := want[st.IndexName]; !ok || st.Scans != scans {
+			t.Fatalf("stats = %+v, want scans %v", stats, want)
+		}
+	}
+}
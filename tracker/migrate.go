@@ -0,0 +1,300 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is a versioned schema change. Up applies it and Down reverts it; both run
+// inside the transaction that also records the version in schema_migrations.
+type Migration struct {
+	Up      func(tx Tx) error
+	Down    func(tx Tx) error
+	Version string
+}
+
+// MigrationStatus reports whether a registered migration has been applied and when.
+type MigrationStatus struct {
+	AppliedAt *time.Time
+	Version   string
+	Applied   bool
+}
+
+// schemaMigration is a row of the schema_migrations history table.
+type schemaMigration struct {
+	AppliedAt time.Time `gorm:"not null"`
+	Version   string    `gorm:"primaryKey;size:255"`
+}
+
+// TableName pins the history table name regardless of the naming strategy.
+func (schemaMigration) TableName() string { return "schema_migrations" }
+
+// Migrator applies and reverts a registered, ordered list of migrations.
+type Migrator struct {
+	uow        *UnitOfWork
+	migrations []Migration
+}
+
+// NewMigrator creates a Migrator for the given migrations, kept in registration order.
+func NewMigrator(uow *UnitOfWork, migrations ...Migration) *Migrator {
+	return &Migrator{uow: uow, migrations: migrations}
+}
+
+// Migrate applies the migrations not yet recorded in schema_migrations, in slice order.
+// It is idempotent: running it again with the same list does nothing. Use NewMigrator to
+// roll migrations back or inspect their status.
+func Migrate(ctx context.Context, uow *UnitOfWork, migrations []Migration) error {
+	return NewMigrator(uow, migrations...).Up(ctx)
+}
+
+// ApplyMigration executes a raw-SQL migration (views, stored procedures, extensions) and
+// records version in schema_migrations. An already recorded version is skipped.
+func ApplyMigration(ctx context.Context, uow *UnitOfWork, version, rawSQL string) error {
+	return NewMigrator(uow, Migration{
+		Version: version,
+		Up:      func(tx Tx) error { return tx.Exec(rawSQL) },
+	}).Up(ctx)
+}
+
+// Up applies every registered migration that is not recorded yet, in registration order,
+// each in its own transaction. It stops at the first failure.
+func (r *Migrator) Up(ctx context.Context) error {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range r.migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if err = r.up(ctx, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollback reverts the applied migration with the given version.
+func (r *Migrator) Rollback(ctx context.Context, version string) error {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return err
+	}
+	if _, ok := applied[version]; !ok {
+		return fmt.Errorf("tracker: migration %q is not applied", version)
+	}
+	for _, m := range r.migrations {
+		if m.Version == version {
+			return r.down(ctx, m)
+		}
+	}
+	return fmt.Errorf("tracker: migration %q is not registered", version)
+}
+
+// MigrateDown reverts the last steps applied migrations in reverse registration order.
+// Asking for more steps than there are applied migrations reverts all of them.
+func (r *Migrator) MigrateDown(ctx context.Context, steps int) error {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return err
+	}
+	for i := len(r.migrations) - 1; i >= 0 && steps > 0; i-- {
+		m := r.migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if err = r.down(ctx, m); err != nil {
+			return err
+		}
+		steps--
+	}
+	return nil
+}
+
+// CheckMigrations returns the status of every registered migration in registration order.
+func (r *Migrator) CheckMigrations(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]MigrationStatus, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		st := MigrationStatus{Version: m.Version}
+		if at, ok := applied[m.Version]; ok {
+			st.Applied = true
+			st.AppliedAt = &at
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+// up applies m and records it.
+func (r *Migrator) up(ctx context.Context, m Migration) error {
+	if err := r.uow.writable(); err != nil {
+		return err
+	}
+	return r.uow.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if m.Up != nil {
+			if err := m.Up(gormTx{db: tx}); err != nil {
+				return fmt.Errorf("tracker: migration %q up: %w", m.Version, err)
+			}
+		}
+		return tx.Create(&schemaMigration{Version: m.Version, AppliedAt: time.Now()}).Error
+	})
+}
+
+// down reverts m and removes it from the history.
+func (r *Migrator) down(ctx context.Context, m Migration) error {
+	if m.Down == nil {
+		return fmt.Errorf("tracker: migration %q has no down step", m.Version)
+	}
+	if err := r.uow.writable(); err != nil {
+		return err
+	}
+	return r.uow.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := m.Down(gormTx{db: tx}); err != nil {
+			return fmt.Errorf("tracker: migration %q down: %w", m.Version, err)
+		}
+		return tx.Delete(&schemaMigration{Version: m.Version}).Error
+	})
+}
+
+// applied returns the recorded migrations by version, creating the history table if needed.
+func (r *Migrator) applied(ctx context.Context) (map[string]time.Time, error) {
+	db := r.uow.conn(ctx)
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, err
+	}
+	var rows []schemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		out[row.Version] = row.AppliedAt
+	}
+	return out, nil
+}
+
+// HasTable reports whether table exists, without inspecting the rest of the schema.
+func (r *UnitOfWork) HasTable(ctx context.Context, table string) (bool, error) {
+	db := r.conn(ctx)
+	if db.Migrator().HasTable(table) {
+		return true, nil
+	}
+	return false, reachable(db)
+}
+
+// HasColumn reports whether table exists and has column.
+func (r *UnitOfWork) HasColumn(ctx context.Context, table, column string) (bool, error) {
+	db := r.conn(ctx)
+	if db.Migrator().HasColumn(table, column) {
+		return true, nil
+	}
+	return false, reachable(db)
+}
+
+// CreateTable creates model's table, its indexes and constraints. It does nothing if the
+// table already exists.
+func (r *UnitOfWork) CreateTable(ctx context.Context, model any) error {
+	if err := r.writable(); err != nil {
+		return err
+	}
+	m := r.conn(ctx).Migrator()
+	if m.HasTable(model) {
+		return nil
+	}
+	return m.CreateTable(model)
+}
+
+// DropTable drops model's table. It does nothing if the table does not exist.
+func (r *UnitOfWork) DropTable(ctx context.Context, model any) error {
+	if err := r.writable(); err != nil {
+		return err
+	}
+	return r.conn(ctx).Migrator().DropTable(model)
+}
+
+// reachable tells a negative Migrator answer apart from a failed query, which the
+// Migrator reports as false too.
+func reachable(db *gorm.DB) error {
+	return db.Exec("SELECT 1").Error
+}
+
+// AssertSchemaMatchesModels compares the database schema with what AutoMigrate would create
+// for models, without changing anything, and returns ErrSchemaDrift listing every missing
+// table, missing or extra column and column type mismatch. Types are compared by base name
+// (ignoring sizes and dialect aliases), so it is meant for catching drift in CI rather than
+// as an exact diff.
+func AssertSchemaMatchesModels(ctx context.Context, uow *UnitOfWork, models ...any) error {
+	db := uow.conn(ctx)
+	m := db.Migrator()
+	var problems []string
+	for _, model := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return fmt.Errorf("tracker: parse %T: %w", model, err)
+		}
+		sch := stmt.Schema
+		if !m.HasTable(model) {
+			problems = append(problems, fmt.Sprintf("table %s is missing", sch.Table))
+			continue
+		}
+		cols, err := m.ColumnTypes(model)
+		if err != nil {
+			return fmt.Errorf("tracker: inspect %s: %w", sch.Table, err)
+		}
+		actual := make(map[string]gorm.ColumnType, len(cols))
+		for _, c := range cols {
+			actual[c.Name()] = c
+		}
+		for _, name := range sch.DBNames {
+			f := sch.FieldsByDBName[name]
+			if f.IgnoreMigration {
+				continue
+			}
+			c, ok := actual[name]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("column %s.%s (field %s) is missing", sch.Table, name, f.Name))
+				continue
+			}
+			delete(actual, name)
+			want, got := baseType(db.Dialector.DataTypeOf(f)), baseType(c.DatabaseTypeName())
+			if want != got && !slices.Contains(m.GetTypeAliases(got), want) && !slices.Contains(m.GetTypeAliases(want), got) {
+				problems = append(problems, fmt.Sprintf("column %s.%s has type %s, model wants %s", sch.Table, name, got, want))
+			}
+		}
+		for name := range actual {
+			problems = append(problems, fmt.Sprintf("column %s.%s is not in model %s", sch.Table, name, sch.Name))
+		}
+	}
+	if len(problems) > 0 {
+		slices.Sort(problems)
+		return fmt.Errorf("%w: %s", ErrSchemaDrift, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// baseType reduces a column type to its lower-case base name, e.g. "VARCHAR(255)" to
+// "varchar", with serial types mapped to the integer types they are stored as.
+func baseType(t string) string {
+	t = strings.ToLower(strings.TrimSpace(t))
+	if i := strings.IndexAny(t, "( "); i >= 0 {
+		t = t[:i]
+	}
+	switch t {
+	case "smallserial", "serial2":
+		return "smallint"
+	case "serial", "serial4":
+		return "integer"
+	case "bigserial", "serial8":
+		return "bigint"
+	}
+	return t
+}
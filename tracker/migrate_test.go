@@ -0,0 +1,120 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// tableMigration creates table on Up and drops it on Down.
+func tableMigration(version, table string) Migration {
+	return Migration{
+		Version: version,
+		Up:      func(tx Tx) error { return tx.Exec("CREATE TABLE " + table + " (id INTEGER PRIMARY KEY)") },
+		Down:    func(tx Tx) error { return tx.Exec("DROP TABLE " + table) },
+	}
+}
+
+var migrationTables = []string{"m_one", "m_two", "m_three"}
+
+// newTableMigrator returns a Migrator with one tableMigration per migrationTables entry.
+func newTableMigrator(uow *UnitOfWork) *Migrator {
+	var migrations []Migration
+	for i, table := range migrationTables {
+		migrations = append(migrations, tableMigration(fmt.Sprintf("%03d", i+1), table))
+	}
+	return NewMigrator(uow, migrations...)
+}
+
+func TestMigrateDown(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		steps     int
+		wantTable []bool
+	}{
+		{name: "none", steps: 0, wantTable: []bool{true, true, true}},
+		{name: "one", steps: 1, wantTable: []bool{true, true, false}},
+		{name: "too many", steps: 5, wantTable: []bool{false, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, nil)
+			m := newTableMigrator(uow)
+			if err := m.Up(ctx); err != nil {
+				t.Fatalf("Up: %v", err)
+			}
+			if err := m.MigrateDown(ctx, tt.steps); err != nil {
+				t.Fatalf("MigrateDown: %v", err)
+			}
+			for i, table := range migrationTables {
+				if has, err := uow.HasTable(ctx, table); err != nil || has != tt.wantTable[i] {
+					t.Fatalf("HasTable(%s) = %t, %v, want %t", table, has, err, tt.wantTable[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckMigrations(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name        string
+		downSteps   int
+		wantApplied []bool
+	}{
+		{name: "all applied", wantApplied: []bool{true, true, true}},
+		{name: "last pending", downSteps: 1, wantApplied: []bool{true, true, false}},
+		{name: "all pending", downSteps: 3, wantApplied: []bool{false, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTableMigrator(newTestUoW(t, nil))
+			if err := m.Up(ctx); err != nil {
+				t.Fatalf("Up: %v", err)
+			}
+			if err := m.MigrateDown(ctx, tt.downSteps); err != nil {
+				t.Fatalf("MigrateDown: %v", err)
+			}
+			status, err := m.CheckMigrations(ctx)
+			if err != nil || len(status) != len(tt.wantApplied) {
+				t.Fatalf("CheckMigrations = %+v, %v, want %d entries", status, err, len(tt.wantApplied))
+			}
+			for i, st := range status {
+				if want := fmt.Sprintf("%03d", i+1); st.Version != want || st.Applied != tt.wantApplied[i] || (st.AppliedAt != nil) != st.Applied {
+					t.Fatalf("status[%d] = %+v, want version %s applied %t", i, st, want, tt.wantApplied[i])
+				}
+			}
+		})
+	}
+}
+
+func TestApplyMigration(t *testing.T) {
+	ctx := context.Background()
+	uow := newTestUoW(t, nil)
+	for range 2 {
+		if err := ApplyMigration(ctx, uow, "view-1", "CREATE VIEW v_one AS SELECT 1 AS one"); err != nil {
+			t.Fatalf("ApplyMigration: %v", err)
+		}
+	}
+	var one int
+	if err := uow.mustRoot().Raw("SELECT one FROM v_one").Scan(&one).Error; err != nil || one != 1 {
+		t.Fatalf("view = %d, %v, want 1", one, err)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	uow := newTestUoW(t, nil)
+	migrations := []Migration{tableMigration("001", "m_one"), tableMigration("002", "m_two")}
+	for range 2 {
+		if err := Migrate(ctx, uow, migrations); err != nil {
+			t.Fatalf("Migrate: %v", err)
+		}
+	}
+	for _, table := range []string{"m_one", "m_two"} {
+		if has, err := uow.HasTable(ctx, table); err != nil || !has {
+			t.Fatalf("HasTable(%s) = %t, %v, want true", table, has, err)
+		}
+	}
+}
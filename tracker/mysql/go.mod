@@ -0,0 +1,12 @@
+module gojogo/tracker/mysql
+
+go 1.25.1
+
+require (
+	github.com/go-sql-driver/mysql v1.8.1
+	gojogo v0.0.0
+	gorm.io/driver/mysql v1.6.0
+	gorm.io/gorm v1.31.1
+)
+
+replace gojogo => ../..
@@ -0,0 +1,25 @@
+// Package mysql registers the MySQL dialect with the tracker on import:
+//
+//	import _ "gojogo/tracker/mysql"
+//
+// After that, tracker.NewWithDialect accepts tracker.DialectMySQL, and New detects it
+// from go-sql-driver/mysql connections. It is a separate module so that the tracker itself does
+// not depend on the MySQL driver.
+package mysql
+
+import (
+	"database/sql"
+
+	"gojogo/tracker"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func init() {
+	tracker.RegisterDialect(string(tracker.DialectMySQL), Dialector)
+}
+
+// Dialector returns the GORM MySQL dialector for db, as registered with the tracker.
+func Dialector(db *sql.DB) gorm.Dialector {
+	return mysql.New(mysql.Config{Conn: db})
+}
@@ -0,0 +1,55 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	"gojogo/tracker"
+)
+
+type registeredItem struct {
+	Name string
+	ID   uint
+}
+
+// TestRegistered runs against the database in TRACKER_MYSQL_DSN, e.g. a MySQL
+// container in CI, and is skipped without it.
+func TestRegistered(t *testing.T) {
+	dsn := os.Getenv("TRACKER_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TRACKER_MYSQL_DSN is not set")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	tests := []struct {
+		name    string
+		dialect tracker.Dialect
+	}{
+		{name: "explicit", dialect: tracker.DialectMySQL},
+		{name: "detected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			uow, err := tracker.NewWithDialect(db, tt.dialect, tracker.WithAutoMigrate(&registeredItem{}))
+			if err != nil {
+				t.Fatalf("NewWithDialect: %v", err)
+			}
+			t.Cleanup(func() { _ = uow.DropTable(ctx, &registeredItem{}) })
+			uow.Add(&registeredItem{Name: "a"})
+			if err := uow.Commit(ctx); err != nil {
+				t.Fatalf("Commit: %v", err)
+			}
+			if n, err := uow.Count(ctx, &registeredItem{}); err != nil || n != 1 {
+				t.Fatalf("Count = %d, %v, want 1", n, err)
+			}
+		})
+	}
+}
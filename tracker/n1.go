@@ -0,0 +1,73 @@
+package tracker
+
+import (
+	"log/slog"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// WithN1Detection counts the SELECT statements issued through each UnitOfWork and logs a
+// warning on logger, with the query count and the calling function, whenever more than
+// threshold of them accumulate: the typical signature of an N+1 loop that should preload.
+// The count restarts at every Find and PreloadFirst call, which begin a new read, and after
+// every warning. A nil logger disables detection.
+func WithN1Detection(threshold int, logger *slog.Logger) Option {
+	return func(o *options) {
+		o.n1Threshold = threshold
+		o.n1Logger = logger
+	}
+}
+
+// n1Detector is the per-UnitOfWork state behind WithN1Detection.
+type n1Detector struct {
+	logger    *slog.Logger
+	selects   atomic.Int64
+	threshold int64
+}
+
+// observe is registered as a SQL hook.
+func (d *n1Detector) observe(sql string, _ []any, _ time.Duration) {
+	if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(sql)), "SELECT") {
+		return
+	}
+	n := d.selects.Add(1)
+	if n <= d.threshold || !d.selects.CompareAndSwap(n, 0) {
+		return
+	}
+	d.logger.Warn("tracker: possible N+1 query pattern",
+		slog.Int64("queries", n),
+		slog.Int64("threshold", d.threshold),
+		slog.String("caller", externalCaller()),
+	)
+}
+
+// reset restarts the count at the start of a top-level read. d may be nil.
+func (d *n1Detector) reset() {
+	if d != nil {
+		d.selects.Store(0)
+	}
+}
+
+// trackerPkg is this package's import path, used to skip its own frames.
+var trackerPkg = reflect.TypeFor[n1Detector]().PkgPath()
+
+// externalCaller returns the first function on the stack outside GORM, this package
+// and the runtime.
+func externalCaller() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, trackerPkg+".") &&
+			!strings.HasPrefix(f.Function, "gorm.io/") &&
+			!strings.HasPrefix(f.Function, "runtime.") {
+			return f.Function
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
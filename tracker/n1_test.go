@@ -0,0 +1,77 @@
+package tracker
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+type n1Customer struct {
+	Name string
+	ID   uint
+}
+
+type n1Order struct {
+	N1CustomerID uint
+	ID           uint
+}
+
+func TestN1Detection(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		read     func(t *testing.T, uow *UnitOfWork)
+		name     string
+		wantWarn bool
+	}{
+		{name: "orders loaded per customer", wantWarn: true, read: func(t *testing.T, uow *UnitOfWork) {
+			customers, err := Find[n1Customer](ctx, uow)
+			if err != nil {
+				t.Fatalf("Find: %v", err)
+			}
+			for _, c := range customers {
+				var orders []n1Order
+				if err := uow.FindAll(ctx, &orders, Where("n1_customer_id", "=", c.ID)); err != nil || len(orders) != 5 {
+					t.Fatalf("orders of %d = %d, %v, want 5", c.ID, len(orders), err)
+				}
+			}
+		}},
+		{name: "orders loaded at once", read: func(t *testing.T, uow *UnitOfWork) {
+			if _, err := Find[n1Customer](ctx, uow); err != nil {
+				t.Fatalf("Find: %v", err)
+			}
+			if _, err := Find[n1Order](ctx, uow); err != nil {
+				t.Fatalf("Find: %v", err)
+			}
+		}},
+		{name: "separate reads", read: func(t *testing.T, uow *UnitOfWork) {
+			for range 10 {
+				if _, err := Find[n1Customer](ctx, uow); err != nil {
+					t.Fatalf("Find: %v", err)
+				}
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			uow := newTestUoW(t, []any{&n1Customer{}, &n1Order{}},
+				WithN1Detection(5, slog.New(slog.NewTextHandler(&logs, nil))))
+			for range 10 {
+				c := &n1Customer{Name: "c"}
+				uow.Add(c)
+				mustCommit(t, uow)
+				for range 5 {
+					uow.Add(&n1Order{N1CustomerID: c.ID})
+				}
+			}
+			mustCommit(t, uow)
+
+			tt.read(t, uow)
+			if got := strings.Contains(logs.String(), "level=WARN"); got != tt.wantWarn {
+				t.Fatalf("warned = %t, want %t; log:\n%s", got, tt.wantWarn, logs.String())
+			}
+		})
+	}
+}
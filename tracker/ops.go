@@ -0,0 +1,247 @@
+package tracker
+
+import (
+	"context"
+
+	"gorm.io/gorm/clause"
+)
+
+// OpKind identifies what a PendingOp does on commit.
+type OpKind int
+
+// Kinds of pending operations, listed in the order Commit executes them.
+const (
+	Create OpKind = iota
+	Update
+	Delete
+	Custom
+)
+
+// String returns the lower-case name of the kind.
+func (k OpKind) String() string {
+	switch k {
+	case Create:
+		return "create"
+	case Update:
+		return "update"
+	case Delete:
+		return "delete"
+	case Custom:
+		return "custom"
+	}
+	return "unknown"
+}
+
+// Priority orders custom operations relative to the entity phases of a commit.
+type Priority int
+
+// Custom operation priorities; see DoWithPriority.
+const (
+	// PriorityFirst runs before the creates.
+	PriorityFirst Priority = iota - 1
+	// PriorityNormal runs after the deletes. It is the priority of operations queued with Do.
+	PriorityNormal
+	// PriorityLast runs after all other work.
+	PriorityLast
+)
+
+// PendingOp describes one queued item. Entity is set for Create, Update and Delete;
+// Op and Priority are set for Custom.
+type PendingOp struct {
+	Entity   any
+	Op       Operation
+	Kind     OpKind
+	Priority Priority
+}
+
+// queuedOp is a custom operation with the priority it was queued with.
+type queuedOp struct {
+	op       Operation
+	priority Priority
+}
+
+// PendingOperations returns the queued items in the order Commit would execute them.
+func (r *UnitOfWork) PendingOperations() []PendingOp {
+	ops := r.snapshot().pendingOps()
+	for i := range ops {
+		ops[i].Entity = entityOf(ops[i].Entity)
+	}
+	return ops
+}
+
+// Intercept registers fn to run over every pending item at commit time, before any SQL is
+// issued. fn may replace the item, e.g. to normalize or encrypt fields; returning an error
+// aborts the commit. Interceptors run in registration order and survive Clear.
+func (r *UnitOfWork) Intercept(fn func(op PendingOp) (PendingOp, error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interceptors = append(r.interceptors, fn)
+}
+
+// ChangeSet is a record of the entities written by a commit, kept for replaying it later,
+// e.g. to recover after data loss. Custom operations are not part of it.
+type ChangeSet struct {
+	Created []any
+	Updated []any
+	Deleted []any
+}
+
+// ChangeSet returns the entity changes currently queued. Capture it before SaveChanges to
+// keep a record of what the commit writes; entities are shared, not copied, so primary keys
+// assigned on insert are visible through it afterwards.
+func (r *UnitOfWork) ChangeSet() ChangeSet {
+	c := r.snapshot()
+	return ChangeSet{Created: c.createdEntities(), Updated: c.updates, Deleted: c.deletes}
+}
+
+// Replay re-applies cs in one transaction through a scoped UnitOfWork, leaving r's own
+// queue untouched. Creates that collide with existing rows are skipped
+// (ON CONFLICT DO NOTHING), so replaying an already applied change set is harmless.
+func (r *UnitOfWork) Replay(ctx context.Context, cs ChangeSet) error {
+	s := r.WithClause(clause.OnConflict{DoNothing: true})
+	for _, e := range cs.Created {
+		s.Add(e)
+	}
+	for _, e := range cs.Updated {
+		s.Update(e)
+	}
+	for _, e := range cs.Deleted {
+		s.RegisterDelete(e)
+	}
+	return s.SaveChanges(ctx)
+}
+
+// ConditionalAdd tracks an entity to be created on commit only if condition returns true
+// when evaluated, just before the create step.
+func (r *UnitOfWork) ConditionalAdd(entity any, condition func() bool) {
+	r.Add(conditionalEntity{entity: entity, condition: condition})
+}
+
+// conditionalEntity is a create queued by ConditionalAdd.
+type conditionalEntity struct {
+	entity    any
+	condition func() bool
+}
+
+// entityOf unwraps entities queued by ConditionalAdd.
+func entityOf(e any) any {
+	if ce, ok := e.(conditionalEntity); ok {
+		return ce.entity
+	}
+	return e
+}
+
+// createdEntities returns the queued creates with ConditionalAdd wrappers removed.
+func (c changes) createdEntities() []any {
+	out := make([]any, len(c.creates))
+	for i, e := range c.creates {
+		out[i] = entityOf(e)
+	}
+	return out
+}
+
+// resolveConditions evaluates ConditionalAdd predicates, dropping the creates that fail them.
+func (c changes) resolveConditions() changes {
+	creates := make([]any, 0, len(c.creates))
+	for _, e := range c.creates {
+		if ce, ok := e.(conditionalEntity); ok {
+			if !ce.condition() {
+				continue
+			}
+			e = ce.entity
+		}
+		creates = append(creates, e)
+	}
+	c.creates = creates
+	return c
+}
+
+// pendingOps flattens c into execution order.
+func (c changes) pendingOps() []PendingOp {
+	out := make([]PendingOp, 0, len(c.creates)+len(c.updates)+len(c.deletes)+len(c.ops))
+	customs := func(p Priority) {
+		for _, q := range c.ops {
+			if q.priority == p {
+				out = append(out, PendingOp{Kind: Custom, Op: q.op, Priority: p})
+			}
+		}
+	}
+	customs(PriorityFirst)
+	for _, e := range c.creates {
+		out = append(out, PendingOp{Kind: Create, Entity: e})
+	}
+	for _, e := range c.updates {
+		out = append(out, PendingOp{Kind: Update, Entity: e})
+	}
+	for _, e := range c.deletes {
+		out = append(out, PendingOp{Kind: Delete, Entity: e})
+	}
+	customs(PriorityNormal)
+	customs(PriorityLast)
+	return out
+}
+
+// withItems returns a copy of c whose queued work is replaced by items, grouped by kind.
+func (c changes) withItems(items []PendingOp) changes {
+	out := c
+	out.creates, out.updates, out.deletes, out.ops = nil, nil, nil, nil
+	for _, op := range items {
+		switch op.Kind {
+		case Create:
+			out.creates = append(out.creates, op.Entity)
+		case Update:
+			out.updates = append(out.updates, op.Entity)
+		case Delete:
+			out.deletes = append(out.deletes, op.Entity)
+		case Custom:
+			out.ops = append(out.ops, queuedOp{op: op.Op, priority: op.Priority})
+		}
+	}
+	return out
+}
+
+// intercept passes every pending item through the interceptors and regroups the results
+// by kind, so an interceptor may also change an item's kind.
+func (c changes) intercept(interceptors []func(PendingOp) (PendingOp, error)) (changes, error) {
+	if len(interceptors) == 0 {
+		return c, nil
+	}
+	items := c.pendingOps()
+	for i := range items {
+		for _, fn := range interceptors {
+			var err error
+			if items[i], err = fn(items[i]); err != nil {
+				return c, err
+			}
+		}
+	}
+	return c.withItems(items), nil
+}
+
+// dequeue removes already committed items from the front of the queues. Committed items
+// always form a prefix of each queue, and of each priority within the custom operations,
+// because new work is only ever appended.
+func (r *UnitOfWork) dequeue(items []PendingOp) {
+	var n [Custom + 1]int
+	customs := map[Priority]int{}
+	for _, op := range items {
+		n[op.Kind]++
+		if op.Kind == Custom {
+			customs[op.Priority]++
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.toCreate = r.toCreate[min(n[Create], len(r.toCreate)):]
+	r.toUpdate = r.toUpdate[min(n[Update], len(r.toUpdate)):]
+	r.toDelete = r.toDelete[min(n[Delete], len(r.toDelete)):]
+	ops := r.ops[:0:0]
+	for _, q := range r.ops {
+		if customs[q.priority] > 0 {
+			customs[q.priority]--
+			continue
+		}
+		ops = append(ops, q)
+	}
+	r.ops = ops
+}
@@ -0,0 +1,130 @@
+package tracker
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// versionColumn is the column holding the version of Versioned models.
+const versionColumn = "version"
+
+// Versioned is implemented by models whose version, stored in the "version" column, is
+// checked by WithOptimisticLock. Version reports the version the entity was loaded with.
+type Versioned interface {
+	Version() int64
+}
+
+// WithOptimisticLock makes Commit update entities only if their row still has the version
+// they were loaded with, incrementing it in the same UPDATE. A model opts in with an integer
+// field tagged gorm:"version", or by implementing Versioned with a field mapped to the
+// version column. When another writer changed the row first, no row matches and Commit
+// fails with an error wrapping ErrConflict. The entity's version field holds the new
+// version after a successful commit. Other models are saved as usual.
+func WithOptimisticLock() Option {
+	return func(o *options) { o.optimisticLock = true }
+}
+
+// save writes an updated entity. Under WithOptimisticLock and Tenancy it issues an UPDATE
+// filtered by the row's version or tenant rather than Save, which inserts the entity when no
+// row matches; matching no row fails with ErrConflict or ErrNotFound respectively.
+func (r *UnitOfWork) save(tx *gorm.DB, entity any) error {
+	if !r.opts.optimisticLock && r.opts.tenant == nil {
+		return tx.Save(entity).Error
+	}
+	rv := reflect.Indirect(reflect.ValueOf(entity))
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		for i := range rv.Len() {
+			el := rv.Index(i)
+			if el.Kind() != reflect.Pointer {
+				el = el.Addr()
+			}
+			if err := r.save(tx, el.Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(entity); err != nil {
+		return err
+	}
+	sch := stmt.Schema
+	ctx := tx.Statement.Context
+	var f *schema.Field
+	if r.opts.optimisticLock {
+		f = versionField(sch, entity)
+	}
+	if (f == nil && r.opts.tenant == nil) || rv.Kind() != reflect.Struct || !hasPrimaryKey(tx, sch, rv) {
+		return tx.Save(entity).Error
+	}
+
+	q := tx.Model(entity)
+	var current int64
+	if f != nil {
+		v, _ := f.ValueOf(ctx, rv)
+		current = reflect.ValueOf(v).Convert(reflect.TypeFor[int64]()).Int()
+		if vs, ok := entity.(Versioned); ok {
+			current = vs.Version()
+		}
+		if err := f.Set(ctx, rv, current+1); err != nil {
+			return err
+		}
+		q = q.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: f.DBName}, Value: current})
+	}
+	res := q.Select("*").Updates(entity)
+	if tx.DryRun {
+		// Nothing was written (see PrecomputeSQL), so no row can have matched.
+		if f != nil {
+			_ = f.Set(ctx, rv, current)
+		}
+		return res.Error
+	}
+	if res.Error == nil && res.RowsAffected == 0 {
+		if f != nil {
+			res.Error = fmt.Errorf("tracker: update %s at version %d: %w", sch.Name, current, ErrConflict)
+		} else {
+			res.Error = fmt.Errorf("tracker: update %s: %w", sch.Name, ErrNotFound)
+		}
+	}
+	if res.Error != nil && f != nil {
+		_ = f.Set(ctx, rv, current)
+	}
+	return res.Error
+}
+
+// versionField returns the integer field holding entity's version: the one tagged
+// gorm:"version", or the version column of a Versioned model.
+func versionField(sch *schema.Schema, entity any) *schema.Field {
+	var f *schema.Field
+	for _, sf := range sch.Fields {
+		if _, ok := sf.TagSettings["VERSION"]; ok {
+			f = sf
+			break
+		}
+	}
+	if _, ok := entity.(Versioned); ok && f == nil {
+		f = sch.LookUpField(versionColumn)
+	}
+	if f == nil || f.DBName == "" || f.FieldType.Kind() < reflect.Int || f.FieldType.Kind() > reflect.Uint64 {
+		return nil
+	}
+	return f
+}
+
+// hasPrimaryKey reports whether rv has every primary key value set, so saving it updates
+// an existing row.
+func hasPrimaryKey(tx *gorm.DB, sch *schema.Schema, rv reflect.Value) bool {
+	if len(sch.PrimaryFields) == 0 {
+		return false
+	}
+	for _, pk := range sch.PrimaryFields {
+		if _, zero := pk.ValueOf(tx.Statement.Context, rv); zero {
+			return false
+		}
+	}
+	return true
+}
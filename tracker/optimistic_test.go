@@ -0,0 +1,125 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type lockedAccount struct {
+	Owner   string
+	ID      uint
+	Balance int
+	Ver     int `gorm:"version"`
+}
+
+type versionedAccount struct {
+	Owner string
+	ID    uint
+	Rev   int64 `gorm:"column:version"`
+}
+
+func (a versionedAccount) Version() int64 { return a.Rev }
+
+func TestOptimisticLockConcurrentUpdates(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		model   any
+		load    func(uow *UnitOfWork, id uint) (any, error)
+		version func(entity any) int64
+		name    string
+	}{
+		{
+			name:  "version tag",
+			model: &lockedAccount{},
+			load: func(uow *UnitOfWork, id uint) (any, error) {
+				var a lockedAccount
+				return &a, uow.First(ctx, &a, id)
+			},
+			version: func(e any) int64 { return int64(e.(*lockedAccount).Ver) },
+		},
+		{
+			name:  "Versioned interface",
+			model: &versionedAccount{},
+			load: func(uow *UnitOfWork, id uint) (any, error) {
+				var a versionedAccount
+				return &a, uow.First(ctx, &a, id)
+			},
+			version: func(e any) int64 { return e.(*versionedAccount).Rev },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{tt.model}, WithOptimisticLock())
+			if err := uow.mustRoot().Create(tt.model).Error; err != nil {
+				t.Fatalf("seed: %v", err)
+			}
+
+			var (
+				wg        sync.WaitGroup
+				mu        sync.Mutex
+				conflicts int
+				succeeded int
+			)
+			start := make(chan struct{})
+			for range 2 {
+				entity, err := tt.load(uow, 1)
+				if err != nil {
+					t.Fatalf("load: %v", err)
+				}
+				wg.Go(func() {
+					w := uow.Clone()
+					w.Update(entity)
+					<-start
+					err := w.Commit(ctx)
+					mu.Lock()
+					defer mu.Unlock()
+					switch {
+					case err == nil:
+						succeeded++
+					case errors.Is(err, ErrConflict):
+						conflicts++
+					default:
+						t.Errorf("Commit: %v", err)
+					}
+				})
+			}
+			close(start)
+			wg.Wait()
+			if succeeded != 1 || conflicts != 1 {
+				t.Fatalf("succeeded = %d, conflicts = %d; want 1 and 1", succeeded, conflicts)
+			}
+			stored, err := tt.load(uow, 1)
+			if err != nil || tt.version(stored) != 1 {
+				t.Fatalf("stored version = %d (%v), want 1", tt.version(stored), err)
+			}
+		})
+	}
+}
+
+func TestOptimisticLockPrecomputeSQL(t *testing.T) {
+	ctx := context.Background()
+	uow := newTestUoW(t, []any{&lockedAccount{}}, WithOptimisticLock())
+	acct := &lockedAccount{Owner: "ada"}
+	uow.Add(acct)
+	mustCommit(t, uow)
+
+	acct.Balance = 10
+	uow.Update(acct)
+	stmts, err := uow.PrecomputeSQL(ctx)
+	if err != nil {
+		t.Fatalf("PrecomputeSQL: %v", err)
+	}
+	if len(stmts) != 1 || !strings.Contains(stmts[0], "UPDATE") {
+		t.Fatalf("statements = %q, want one UPDATE", stmts)
+	}
+	if acct.Ver != 0 {
+		t.Fatalf("version after dry run = %d, want 0", acct.Ver)
+	}
+	mustCommit(t, uow)
+	if acct.Ver != 1 {
+		t.Fatalf("version after commit = %d, want 1", acct.Ver)
+	}
+}
@@ -0,0 +1,325 @@
+package tracker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Option configures a UnitOfWork at construction time.
+type Option func(*options)
+
+// options holds the configuration a UnitOfWork shares with every UoW scoped from it.
+type options struct {
+	// beforeSave validates each tracked entity inside the transaction before it is written.
+	beforeSave func(entity any, phase string) error
+	// afterSave runs inside the transaction right after each tracked entity is written.
+	afterSave func(tx Tx, entity any, phase string) error
+	// publisher receives recorded domain events after a successful commit.
+	publisher EventPublisher
+	// publishFn receives the messages queued by OnCommitPublish after a successful commit.
+	publishFn PublishFunc
+	// maxRetries is how many times Commit retries a failed transaction matched by isRetryable.
+	maxRetries  int
+	isRetryable func(error) bool
+	// actor is stamped on Auditable entities by commits; see WithActor.
+	actor string
+	// readOnly makes the queueing methods no-ops and Commit fail; see AsReadOnly.
+	readOnly bool
+	// cancelOn, set by WithCancelOnError, stops the UoW from queueing and committing work
+	// once it is canceled.
+	cancelOn context.Context
+	// strict validates queued entities against their constraints before committing.
+	strict bool
+	// batchSize caps the rows per multi-row INSERT of created entities; 0 inserts them one
+	// by one. See BatchSize.
+	batchSize int
+	// tenant makes commits stamp the tenant on every entity, and update and delete rows only
+	// through the Tenancy filter, failing when it matches none.
+	tenant *tenant
+	// optimisticLock checks and increments version fields on update; see WithOptimisticLock.
+	optimisticLock bool
+	// recoverPanics turns panics raised while applying a commit into errors.
+	recoverPanics bool
+	// maxCommitDepth caps nested Commit calls on one UoW; 0 means the default of 1.
+	maxCommitDepth int32
+	// resolver picks the connection used for each call based on its context.
+	resolver func(ctx context.Context) *sql.DB
+	// namer overrides GORM's table and column naming.
+	namer schema.Namer
+	// config and dialector, set by NewWithGormConfig, replace the default GORM configuration
+	// and SQLite dialector used to open the root.
+	config    *gorm.Config
+	dialector gorm.Dialector
+	// dialect is the name NewDialect looked the dialector up by.
+	dialect string
+	// dsn is the connection string reported, redacted, by ConnectionString.
+	dsn string
+	// onBegin runs at the start of every transaction Commit opens, before any queued work.
+	onBegin []func(tx *gorm.DB) error
+	// isolation is the isolation level of the transactions Commit opens; LevelDefault leaves
+	// it to the database.
+	isolation sql.IsolationLevel
+	// savepoint, when set, wraps each commit in SAVEPOINT/RELEASE SAVEPOINT of that name.
+	savepoint string
+	// planWriter receives the commit plan written by LogOperations.
+	planWriter io.Writer
+	// sqlHooks receive every statement executed through the UoW.
+	sqlHooks []func(sql string, vars []any, d time.Duration)
+	// migrate lists the models auto-migrated at construction time.
+	migrate []any
+	// countAssocs lists the associations counted by PreloadCount on this UoW.
+	countAssocs []string
+	// models registers the Go types Import restores rows into, along with migrate.
+	models []any
+	// n1Threshold enables N+1 detection when positive; warnings go to n1Logger.
+	n1Threshold int
+	n1Logger    *slog.Logger
+	// n1 is the detector newUnitOfWork creates for WithN1Detection, shared by its scopes.
+	n1 *n1Detector
+	// queries records the SELECTs run on SQLite, for IndexUsageStats; shared like n1.
+	queries *queryLog
+}
+
+// Phases reported to entity hooks such as WithBeforeSave.
+const (
+	PhaseCreate = "create"
+	PhaseUpdate = "update"
+	PhaseDelete = "delete"
+)
+
+// newOptions applies opts over the defaults.
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// addOnBegin appends a transaction setup step without aliasing the slice of the UoW
+// these options were copied from.
+func (o *options) addOnBegin(fn func(tx *gorm.DB) error) {
+	o.onBegin = append(slices.Clip(o.onBegin), fn)
+}
+
+// rootSQLHooks returns the SQL hooks for a new UnitOfWork on dialect, including the
+// per-UoW N+1 detector when enabled and, on SQLite, the query log of IndexUsageStats,
+// which it stores in o.n1 and o.queries.
+func (o *options) rootSQLHooks(dialect string) []func(sql string, vars []any, d time.Duration) {
+	hooks := o.sqlHooks
+	if o.n1Threshold > 0 && o.n1Logger != nil {
+		o.n1 = &n1Detector{threshold: int64(o.n1Threshold), logger: o.n1Logger}
+		hooks = append(slices.Clip(hooks), o.n1.observe)
+	}
+	if dialect == "sqlite" {
+		o.queries = &queryLog{}
+		hooks = append(slices.Clip(hooks), o.queries.observe)
+	}
+	return hooks
+}
+
+// gormConfig builds the GORM configuration used to open the root.
+// The caller's config is copied, since gorm.Open modifies the one it is given.
+func (o options) gormConfig() *gorm.Config {
+	cfg := &gorm.Config{}
+	if o.config != nil {
+		c := *o.config
+		cfg = &c
+	}
+	if o.namer != nil {
+		cfg.NamingStrategy = o.namer
+	}
+	return cfg
+}
+
+// configKey identifies the parts of o that end up in gormConfig or select the dialector,
+// for the gormRoots cache.
+func (o options) configKey() string {
+	var parts []string
+	if o.namer != nil {
+		parts = append(parts, fmt.Sprintf("namer=%T%+v", o.namer, o.namer))
+	}
+	if o.config != nil {
+		parts = append(parts, fmt.Sprintf("gorm=%x", hashConfig(o.config)))
+	}
+	if o.dialect != "" {
+		parts = append(parts, "dialect="+o.dialect)
+	}
+	if o.dialector != nil {
+		parts = append(parts, fmt.Sprintf("dialector=%T", o.dialector))
+	}
+	return strings.Join(parts, ";")
+}
+
+// hashConfig hashes the settings of cfg that shape a GORM root. Plain values are hashed as
+// they are, while pluggable fields contribute their type (and map keys) only, so configs
+// built afresh for each UnitOfWork, e.g. with a new Logger, share a root. The caller's
+// Logger and NowFunc instances are applied per UnitOfWork instead; see configSession.
+func hashConfig(cfg *gorm.Config) uint64 {
+	h := fnv.New64a()
+	v := reflect.ValueOf(cfg).Elem()
+	for i := range v.NumField() {
+		f, sf := v.Field(i), v.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fmt.Fprintf(h, "%s=", sf.Name)
+		switch {
+		case sf.Name == "NamingStrategy":
+			fmt.Fprintf(h, "%T%+v", cfg.NamingStrategy, cfg.NamingStrategy)
+		case f.Kind() == reflect.Map:
+			keys := make([]string, 0, f.Len())
+			for _, k := range f.MapKeys() {
+				keys = append(keys, k.String())
+			}
+			slices.Sort(keys)
+			fmt.Fprintf(h, "%v", keys)
+		case f.Kind() == reflect.Interface || f.Kind() == reflect.Func:
+			if !f.IsNil() {
+				fmt.Fprintf(h, "%T", f.Interface())
+			}
+		default:
+			fmt.Fprintf(h, "%v", f.Interface())
+		}
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+// configSession returns root with the caller's Logger and NowFunc, which hashConfig leaves
+// out of the cache key, applied.
+func (o options) configSession(root *gorm.DB) *gorm.DB {
+	if o.config == nil || (o.config.Logger == nil && o.config.NowFunc == nil) {
+		return root
+	}
+	return root.Session(&gorm.Session{Logger: o.config.Logger, NowFunc: o.config.NowFunc})
+}
+
+// txOptions returns the options for the transactions Commit opens, if any.
+func (o options) txOptions() []*sql.TxOptions {
+	if o.isolation == sql.LevelDefault {
+		return nil
+	}
+	return []*sql.TxOptions{{Isolation: o.isolation}}
+}
+
+// WithBeforeSave registers a hook called inside the transaction for every tracked entity
+// right before it is written, with phase PhaseCreate, PhaseUpdate or PhaseDelete.
+// Returning an error rolls the transaction back and is returned from Commit.
+func WithBeforeSave(fn func(entity any, phase string) error) Option {
+	return func(o *options) { o.beforeSave = fn }
+}
+
+// WithAfterSave registers a hook called inside the transaction right after each tracked
+// entity is written, with phase PhaseCreate, PhaseUpdate or PhaseDelete. tx is the commit's
+// transaction, so follow-up writes such as audit rows commit or roll back with the entity.
+// Returning an error rolls the transaction back and is returned from Commit.
+func WithAfterSave(fn func(tx Tx, entity any, phase string) error) Option {
+	return func(o *options) { o.afterSave = fn }
+}
+
+// WithMaxRetries makes Commit retry the whole transaction up to n more times when it fails
+// with a transient error (busy/locked database or serialization failure).
+func WithMaxRetries(n int) Option {
+	return func(o *options) {
+		o.maxRetries = n
+		o.isRetryable = isSerializationFailure
+	}
+}
+
+// WithMaxSaveChangesCallDepth allows up to n Commit/SaveChanges calls to be in progress on
+// the same UnitOfWork at once, e.g. from AfterCommit callbacks that commit again. Calls past
+// the limit fail with ErrMaxDepthExceeded. The default of 1 rejects any reentrant commit.
+func WithMaxSaveChangesCallDepth(n int) Option {
+	return func(o *options) { o.maxCommitDepth = int32(max(n, 1)) }
+}
+
+// WithPanicRecovery makes Commit recover from panics raised while it applies the queued
+// work (Do operations, interceptors, WithBeforeSave and GORM model hooks), rolling the
+// transaction back and returning an error wrapping ErrPanicRecovered instead.
+func WithPanicRecovery(enabled bool) Option {
+	return func(o *options) { o.recoverPanics = enabled }
+}
+
+// WithTransactionTimeout has the database itself bound each commit transaction, which holds
+// even when Go code ignores its context: on PostgreSQL, SET LOCAL lock_timeout and
+// statement_timeout to d; on SQLite, PRAGMA busy_timeout, which bounds the wait for locks
+// held by other connections and stays set on the connection afterwards. Other dialects are
+// left unchanged.
+func WithTransactionTimeout(d time.Duration) Option {
+	ms := d.Milliseconds()
+	return func(o *options) {
+		o.addOnBegin(func(tx *gorm.DB) error {
+			switch tx.Dialector.Name() {
+			case "postgres":
+				if err := tx.Exec(fmt.Sprintf("SET LOCAL lock_timeout = '%dms'", ms)).Error; err != nil {
+					return err
+				}
+				return tx.Exec(fmt.Sprintf("SET LOCAL statement_timeout = '%dms'", ms)).Error
+			case "sqlite":
+				return tx.Exec(fmt.Sprintf("PRAGMA busy_timeout = %d", ms)).Error
+			}
+			return nil
+		})
+	}
+}
+
+// WithIsolationLevel opens the transactions of Commit, BatchCommit and Transaction at level,
+// e.g. sql.LevelSerializable. The default, sql.LevelDefault, uses the database's own default.
+// The level is handed to the driver as a best-effort request: PostgreSQL and MySQL drivers
+// honor the levels they support and fail the commit on the others, while the SQLite driver
+// ignores it, SQLite transactions being serializable already. Commits that join a
+// transaction bound with WithTx keep its level.
+func WithIsolationLevel(level sql.IsolationLevel) Option {
+	return func(o *options) { o.isolation = level }
+}
+
+// WithDSN records the connection string sqlDB was opened with, so ConnectionString can
+// report it. NewFromDSN sets it automatically.
+func WithDSN(dsn string) Option {
+	return func(o *options) { o.dsn = dsn }
+}
+
+// WithConnectionResolver routes every Commit and query to the *sql.DB returned by resolver
+// for the call's context, e.g. a regional primary for writes and a nearby replica for reads.
+// A nil result falls back to the connection passed to New. All resolved databases must
+// share the root's dialect and schema.
+func WithConnectionResolver(resolver func(ctx context.Context) *sql.DB) Option {
+	return func(o *options) { o.resolver = resolver }
+}
+
+// WithNamingStrategy customizes how GORM derives table and column names,
+// e.g. schema.NamingStrategy{TablePrefix: "app_"}.
+func WithNamingStrategy(strategy schema.Namer) Option {
+	return func(o *options) { o.namer = strategy }
+}
+
+// WithSQLLogger calls fn for every statement executed through the UnitOfWork with the SQL
+// as sent to the driver (placeholders included), its bound values and how long it took.
+// GORM's own logger keeps receiving the statements as well.
+func WithSQLLogger(fn func(sql string, vars []any, duration time.Duration)) Option {
+	return func(o *options) { o.sqlHooks = append(slices.Clip(o.sqlHooks), fn) }
+}
+
+// WithAutoMigrate runs AutoMigrate for models while the UnitOfWork is constructed;
+// NewWithOptions returns the migration error, if any.
+func WithAutoMigrate(models ...any) Option {
+	return func(o *options) { o.migrate = append(slices.Clip(o.migrate), models...) }
+}
+
+// WithModels registers models with the UnitOfWork, so Import can map the tables in a dump
+// back to their Go types. Models passed to WithAutoMigrate are registered as well.
+func WithModels(models ...any) Option {
+	return func(o *options) { o.models = append(slices.Clip(o.models), models...) }
+}
@@ -0,0 +1,76 @@
+package tracker
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type isolationOrder struct {
+	Status string
+	ID     uint
+}
+
+// TestIsolationLevelNoPhantomRead interleaves a reader that counts rows twice within one
+// commit with a writer inserting a matching row in between. SQLite serializes the two
+// transactions, so the reader must not see the phantom row.
+func TestIsolationLevelNoPhantomRead(t *testing.T) {
+	tests := []struct {
+		name  string
+		level sql.IsolationLevel
+	}{
+		{name: "default", level: sql.LevelDefault},
+		{name: "serializable", level: sql.LevelSerializable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newTestDB(t)
+			reader, err := NewWithOptions(db, WithIsolationLevel(tt.level), WithAutoMigrate(&isolationOrder{}))
+			if err != nil {
+				t.Fatalf("NewWithOptions: %v", err)
+			}
+			writer, err := NewWithOptions(db)
+			if err != nil {
+				t.Fatalf("NewWithOptions: %v", err)
+			}
+			reader.Add(&isolationOrder{Status: "open"})
+			mustCommit(t, reader)
+
+			var first, second int64
+			var writeErr error
+			started := make(chan struct{})
+			var wg sync.WaitGroup
+			reader.Do(func(tx Tx) error {
+				db := tx.(interface{ gormDB() *gorm.DB }).gormDB()
+				if err := db.Model(&isolationOrder{}).Where("status = ?", "open").Count(&first).Error; err != nil {
+					return err
+				}
+				wg.Go(func() {
+					close(started)
+					writer.Add(&isolationOrder{Status: "open"})
+					writeErr = writer.Commit(ctx)
+				})
+				<-started
+				time.Sleep(100 * time.Millisecond)
+				return db.Model(&isolationOrder{}).Where("status = ?", "open").Count(&second).Error
+			})
+			mustCommit(t, reader)
+			wg.Wait()
+
+			if writeErr != nil {
+				t.Fatalf("writer Commit: %v", writeErr)
+			}
+			if first != second {
+				t.Fatalf("phantom read: counted %d then %d rows in one transaction", first, second)
+			}
+			if n, err := reader.Count(ctx, &isolationOrder{}); err != nil || n != 2 {
+				t.Fatalf("Count = %d, %v, want 2 after both commits", n, err)
+			}
+		})
+	}
+}
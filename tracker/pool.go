@@ -0,0 +1,165 @@
+package tracker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// ErrPoolClosed is returned by Acquire once the TransactionPool has been closed.
+var ErrPoolClosed = errors.New("tracker: transaction pool closed")
+
+// TransactionPool keeps a fixed number of transactions already begun so hot paths
+// can skip the BEGIN round trip. Each lent transaction is replaced in the background
+// once it is committed or rolled back.
+type TransactionPool struct {
+	uow *UnitOfWork
+	// txs holds one entry per slot. A nil entry means the slot failed to pre-begin
+	// and Acquire must begin it on demand.
+	txs    chan *gorm.DB
+	mu     sync.Mutex
+	closed bool
+}
+
+// PrebegunTx is a transaction lent by a TransactionPool. It implements Tx and must be
+// finalized with Commit or Rollback exactly once.
+type PrebegunTx struct {
+	gormTx
+	pool *TransactionPool
+	mu   sync.Mutex
+	done bool
+}
+
+// NewTransactionPool creates a pool of size pre-begun transactions on the UoW root.
+// It waits for the initial transactions and returns the first BEGIN error, if any.
+func NewTransactionPool(uow *UnitOfWork, size int) (*TransactionPool, error) {
+	if size <= 0 {
+		size = 1
+	}
+	p := &TransactionPool{uow: uow, txs: make(chan *gorm.DB, size)}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, size)
+	for range size {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tx, err := p.begin()
+			if err != nil {
+				errs <- err
+				return
+			}
+			p.txs <- tx
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		_ = p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// Acquire lends a pre-begun transaction, waiting until one is available or ctx is done.
+func (r *TransactionPool) Acquire(ctx context.Context) (*PrebegunTx, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case tx, ok := <-r.txs:
+		if !ok {
+			return nil, ErrPoolClosed
+		}
+		if tx == nil {
+			var err error
+			if tx, err = r.begin(); err != nil {
+				r.put(nil) // keep the slot so the pool does not shrink
+				return nil, err
+			}
+		}
+		return &PrebegunTx{gormTx: gormTx{db: tx.WithContext(ctx)}, pool: r}, nil
+	}
+}
+
+// Close rolls back all idle transactions. Transactions currently lent out are not
+// affected, but they are not replaced once finalized.
+func (r *TransactionPool) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.txs)
+	r.mu.Unlock()
+
+	var errs []error
+	for tx := range r.txs {
+		if tx != nil {
+			errs = append(errs, tx.Rollback().Error)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// begin starts a transaction detached from any request context, since it may outlive it.
+func (r *TransactionPool) begin() (*gorm.DB, error) {
+	tx := r.uow.conn(context.Background()).Begin()
+	return tx, tx.Error
+}
+
+// put returns a slot to the pool, rolling back tx instead if the pool was closed.
+func (r *TransactionPool) put(tx *gorm.DB) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		if tx != nil {
+			tx.Rollback()
+		}
+		return
+	}
+	r.txs <- tx
+}
+
+// refill pre-begins a replacement transaction in the background.
+func (r *TransactionPool) refill() {
+	go func() {
+		tx, err := r.begin()
+		if err != nil {
+			tx = nil
+		}
+		r.put(tx)
+	}()
+}
+
+// Commit commits the transaction and schedules a replacement in the pool.
+func (r *PrebegunTx) Commit() error {
+	if err := r.finish(); err != nil {
+		return err
+	}
+	return r.db.Commit().Error
+}
+
+// Rollback rolls back the transaction and schedules a replacement in the pool.
+func (r *PrebegunTx) Rollback() error {
+	if err := r.finish(); err != nil {
+		return err
+	}
+	return r.db.Rollback().Error
+}
+
+// finish marks the transaction as finalized and asks the pool for a replacement.
+func (r *PrebegunTx) finish() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done {
+		return sql.ErrTxDone
+	}
+	r.done = true
+	r.pool.refill()
+	return nil
+}
@@ -0,0 +1,87 @@
+package tracker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+type pooledRow struct {
+	Name string
+	ID   uint
+}
+
+func TestTransactionPool(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		finish    func(tx *PrebegunTx) error
+		name      string
+		wantCount int64
+	}{
+		{name: "commit", finish: (*PrebegunTx).Commit, wantCount: 1},
+		{name: "rollback", finish: (*PrebegunTx).Rollback, wantCount: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&pooledRow{}})
+			pool, err := NewTransactionPool(uow, 2)
+			if err != nil {
+				t.Fatalf("NewTransactionPool: %v", err)
+			}
+			t.Cleanup(func() { _ = pool.Close() })
+
+			tx, err := pool.Acquire(ctx)
+			if err != nil {
+				t.Fatalf("Acquire: %v", err)
+			}
+			if err := tx.Exec("INSERT INTO pooled_rows (name) VALUES (?)", "a"); err != nil {
+				t.Fatalf("Exec: %v", err)
+			}
+			if err := tt.finish(tx); err != nil {
+				t.Fatalf("finish: %v", err)
+			}
+			if err := tt.finish(tx); !errors.Is(err, sql.ErrTxDone) {
+				t.Fatalf("second finish = %v, want sql.ErrTxDone", err)
+			}
+			if n, err := uow.Count(ctx, &pooledRow{}); err != nil || n != tt.wantCount {
+				t.Fatalf("Count = %d, %v, want %d", n, err, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestTransactionPoolAcquire(t *testing.T) {
+	uow := newTestUoW(t, []any{&pooledRow{}})
+	pool, err := NewTransactionPool(uow, 1)
+	if err != nil {
+		t.Fatalf("NewTransactionPool: %v", err)
+	}
+	tx, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Acquire on an exhausted pool = %v, want context.DeadlineExceeded", err)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	refilled, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire after refill: %v", err)
+	}
+	_ = refilled.Rollback()
+
+	if err := pool.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := pool.Acquire(context.Background()); !errors.Is(err, ErrPoolClosed) {
+		t.Fatalf("Acquire after Close = %v, want ErrPoolClosed", err)
+	}
+}
@@ -0,0 +1,12 @@
+module gojogo/tracker/postgres
+
+go 1.25.1
+
+require (
+	github.com/jackc/pgx/v5 v5.7.5
+	gojogo v0.0.0
+	gorm.io/driver/postgres v1.6.0
+	gorm.io/gorm v1.31.1
+)
+
+replace gojogo => ../..
@@ -0,0 +1,25 @@
+// Package postgres registers the PostgreSQL dialect with the tracker on import:
+//
+//	import _ "gojogo/tracker/postgres"
+//
+// After that, tracker.NewWithDialect accepts tracker.DialectPostgres, and New detects it
+// from pgx and lib/pq connections. It is a separate module so that the tracker itself does
+// not depend on the PostgreSQL driver.
+package postgres
+
+import (
+	"database/sql"
+
+	"gojogo/tracker"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func init() {
+	tracker.RegisterDialect(string(tracker.DialectPostgres), Dialector)
+}
+
+// Dialector returns the GORM PostgreSQL dialector for db, as registered with the tracker.
+func Dialector(db *sql.DB) gorm.Dialector {
+	return postgres.New(postgres.Config{Conn: db})
+}
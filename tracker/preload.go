@@ -0,0 +1,86 @@
+package tracker
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// PreloadCount returns a scoped UnitOfWork whose reads fill a <Assoc>Count field with the
+// number of assoc records of each row instead of loading them, via a correlated COUNT(*)
+// subquery. The model must declare the field as read-only, e.g.
+//
+//	OrdersCount int64 `gorm:"->"`
+//
+// Has-one, has-many and many-to-many associations are supported. Calls can be chained to
+// count several associations; an explicit column selection on the same UoW is replaced.
+// The selection also applies to writes, so use the scoped UoW for reads only.
+func (r *UnitOfWork) PreloadCount(assoc string) *UnitOfWork {
+	assocs := append(slices.Clip(r.opts.countAssocs), assoc)
+	s := r.scoped(r.mustRoot().Scopes(func(db *gorm.DB) *gorm.DB {
+		return selectCounts(db, assocs)
+	}).Session(&gorm.Session{}))
+	s.opts.countAssocs = assocs
+	return s
+}
+
+// selectCounts selects the model's columns plus a count subquery for each of assocs.
+func selectCounts(db *gorm.DB, assocs []string) *gorm.DB {
+	stmt := db.Statement
+	model := stmt.Model
+	if model == nil {
+		model = stmt.Dest
+	}
+	if err := stmt.Parse(model); err != nil {
+		_ = db.AddError(err)
+		return db
+	}
+	sch := stmt.Schema
+	cols := []string{stmt.Quote(sch.Table) + ".*"}
+	var vars []any
+	for _, assoc := range assocs {
+		rel, ok := sch.Relationships.Relations[assoc]
+		if !ok {
+			_ = db.AddError(fmt.Errorf("tracker: PreloadCount: %s has no association %s", sch.Name, assoc))
+			return db
+		}
+		sub, subVars, err := countSubquery(stmt, rel)
+		if err != nil {
+			_ = db.AddError(err)
+			return db
+		}
+		col := stmt.NamingStrategy.ColumnName("", assoc+"Count")
+		cols = append(cols, "("+sub+") AS "+stmt.Quote(col))
+		vars = append(vars, subVars...)
+	}
+	return db.Select(strings.Join(cols, ", "), vars...)
+}
+
+// countSubquery builds SELECT COUNT(*) over the rows rel links to the outer row.
+func countSubquery(stmt *gorm.Statement, rel *schema.Relationship) (string, []any, error) {
+	table := rel.FieldSchema.Table
+	switch {
+	case rel.JoinTable != nil:
+		table = rel.JoinTable.Table
+	case rel.Type != schema.HasOne && rel.Type != schema.HasMany:
+		return "", nil, fmt.Errorf("tracker: PreloadCount: %s relation %s is not supported", rel.Type, rel.Name)
+	}
+	var (
+		conds []string
+		vars  []any
+	)
+	for _, ref := range rel.References {
+		fk := stmt.Quote(table) + "." + stmt.Quote(ref.ForeignKey.DBName)
+		switch {
+		case ref.OwnPrimaryKey:
+			conds = append(conds, fk+" = "+stmt.Quote(rel.Schema.Table)+"."+stmt.Quote(ref.PrimaryKey.DBName))
+		case ref.PrimaryValue != "":
+			conds = append(conds, fk+" = ?")
+			vars = append(vars, ref.PrimaryValue)
+		}
+	}
+	return "SELECT COUNT(*) FROM " + stmt.Quote(table) + " WHERE " + strings.Join(conds, " AND "), vars, nil
+}
@@ -0,0 +1,455 @@
+package tracker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Count returns the number of T rows matching the optional conditions.
+// Conditions follow First's inline form, e.g. Count[Order](ctx, uow, "status = ?", "NEW").
+func Count[T any](ctx context.Context, uow *UnitOfWork, conds ...any) (int64, error) {
+	var n int64
+	err := where(uow.conn(ctx).Model(new(T)), conds).Count(&n).Error
+	return n, err
+}
+
+// CountDistinct returns the number of distinct non-NULL values of col among the T rows
+// matching the optional conditions, i.e. SELECT COUNT(DISTINCT col).
+func CountDistinct[T any](ctx context.Context, uow *UnitOfWork, col string, conds ...any) (int64, error) {
+	var n int64
+	err := where(uow.conn(ctx).Model(new(T)), conds).Distinct(col).Count(&n).Error
+	return n, err
+}
+
+// Find returns all T rows matching the optional conditions.
+func Find[T any](ctx context.Context, uow *UnitOfWork, conds ...any) ([]T, error) {
+	uow.opts.n1.reset()
+	var out []T
+	if err := uow.conn(ctx).Find(&out, conds...).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TakeFirst returns the T row with the lowest primary key matching the optional conditions,
+// or ErrNotFound.
+func TakeFirst[T any](ctx context.Context, uow *UnitOfWork, conds ...any) (*T, error) {
+	out := new(T)
+	if err := uow.conn(ctx).First(out, conds...).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TakeLast returns the T row with the highest primary key matching the optional conditions,
+// or ErrNotFound.
+func TakeLast[T any](ctx context.Context, uow *UnitOfWork, conds ...any) (*T, error) {
+	out := new(T)
+	if err := uow.conn(ctx).Last(out, conds...).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FieldChange is a column whose in-memory value differs from the stored one.
+type FieldChange struct {
+	Old, New any
+	// Field is the Go struct field name.
+	Field string
+}
+
+// PendingDiff compares entity, e.g. one tracked with Update, with its row as currently
+// stored, found by primary key, and returns the fields that differ in declaration order.
+// Associations are not compared. It returns ErrNotFound if the row does not exist.
+func PendingDiff[T any](ctx context.Context, uow *UnitOfWork, entity *T) ([]FieldChange, error) {
+	db := uow.conn(ctx)
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(entity); err != nil {
+		return nil, err
+	}
+	sch := stmt.Schema
+	rv := reflect.ValueOf(entity).Elem()
+	q := db.Model(new(T))
+	for _, pk := range sch.PrimaryFields {
+		v, zero := pk.ValueOf(ctx, rv)
+		if zero {
+			return nil, fmt.Errorf("tracker: PendingDiff: %s has no primary key value", sch.Name)
+		}
+		q = q.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: pk.DBName}, Value: v})
+	}
+	stored := new(T)
+	if err := q.Take(stored).Error; err != nil {
+		return nil, err
+	}
+
+	sv := reflect.ValueOf(stored).Elem()
+	var out []FieldChange
+	for _, f := range sch.Fields {
+		if f.DBName == "" {
+			continue
+		}
+		old, _ := f.ValueOf(ctx, sv)
+		cur, _ := f.ValueOf(ctx, rv)
+		if !sameValue(old, cur) {
+			out = append(out, FieldChange{Field: f.Name, Old: old, New: cur})
+		}
+	}
+	return out, nil
+}
+
+// sameValue reports whether two field values are equal, comparing times by instant since
+// stored times come back in a different location and without a monotonic reading.
+func sameValue(a, b any) bool {
+	if da, ok := a.(gorm.DeletedAt); ok {
+		if db, ok := b.(gorm.DeletedAt); ok {
+			a, b = sql.NullTime(da), sql.NullTime(db)
+		}
+	}
+	if na, ok := a.(sql.NullTime); ok {
+		if nb, ok := b.(sql.NullTime); ok {
+			return na.Valid == nb.Valid && (!na.Valid || na.Time.Equal(nb.Time))
+		}
+	}
+	ta, aok := a.(time.Time)
+	tb, bok := b.(time.Time)
+	if aok && bok {
+		return ta.Equal(tb)
+	}
+	pa, aok := a.(*time.Time)
+	pb, bok := b.(*time.Time)
+	if aok && bok {
+		return pa == pb || pa != nil && pb != nil && pa.Equal(*pb)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// ListOptions describes a List query. Zero values leave the corresponding aspect unset.
+type ListOptions struct {
+	// Filter holds column = value conditions, ANDed together.
+	Filter map[string]any
+	// Order is an ORDER BY expression, e.g. "amount DESC, id".
+	Order string
+	// Page is 1-based; values below 1 select the first page. It is ignored unless
+	// PageSize is positive.
+	Page     int
+	PageSize int
+	// Preloads lists the associations loaded with the items.
+	Preloads []string
+	// Distinct removes duplicate rows.
+	Distinct bool
+}
+
+// ListResult is a page of T rows along with the number of rows across all pages.
+type ListResult[T any] struct {
+	Items []T
+	Total int64
+	Page  int
+}
+
+// listRow carries the window-function total alongside each listed row.
+type listRow[T any] struct {
+	Item      T     `gorm:"embedded"`
+	ListTotal int64 `gorm:"column:list_total;->"`
+}
+
+// List returns the T rows selected by opts in a single query, computing Total with a
+// COUNT(*) OVER() window so filtering, ordering and pagination need no second round trip.
+// Only Distinct listings, and pages past the last row, issue a separate count.
+func List[T any](ctx context.Context, uow *UnitOfWork, opts ListOptions) (ListResult[T], error) {
+	res := ListResult[T]{Page: max(opts.Page, 1)}
+	db := uow.conn(ctx).Model(new(T))
+	if len(opts.Filter) > 0 {
+		db = db.Where(opts.Filter)
+	}
+	if opts.Distinct {
+		db = distinct(db, nil)
+	}
+	db = db.Session(&gorm.Session{})
+	page := func(q *gorm.DB) *gorm.DB {
+		if opts.Order != "" {
+			q = q.Order(opts.Order)
+		}
+		if opts.PageSize > 0 {
+			q = q.Limit(opts.PageSize).Offset((res.Page - 1) * opts.PageSize)
+		}
+		for _, p := range opts.Preloads {
+			q = q.Preload(p)
+		}
+		return q
+	}
+
+	if opts.Distinct {
+		if err := uow.conn(ctx).Table("(?) AS list_distinct", db).Count(&res.Total).Error; err != nil {
+			return res, err
+		}
+		err := page(db).Find(&res.Items).Error
+		return res, err
+	}
+
+	var rows []listRow[T]
+	err := page(db.Select("?.*, COUNT(*) OVER() AS list_total", clause.Table{Name: clause.CurrentTable})).
+		Find(&rows).Error
+	if err != nil {
+		return res, err
+	}
+	res.Items = make([]T, len(rows))
+	for i, row := range rows {
+		res.Items[i] = row.Item
+	}
+	switch {
+	case len(rows) > 0:
+		res.Total = rows[0].ListTotal
+	case res.Page > 1 && opts.PageSize > 0:
+		err = db.Count(&res.Total).Error
+	}
+	return res, err
+}
+
+// Number is the set of types SumByGroup can aggregate into.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// SumByGroup returns SUM(sumCol) over the T rows matching the optional conditions, keyed by
+// groupCol, e.g. SumByGroup[Order, float64](ctx, uow, "status", "amount") for the total amount
+// per status. Rows whose groupCol is NULL are summed under the empty key.
+func SumByGroup[T any, V Number](ctx context.Context, uow *UnitOfWork, groupCol, sumCol string, conds ...any) (map[string]V, error) {
+	var rows []struct {
+		GroupKey sql.NullString
+		Total    V
+	}
+	err := where(uow.conn(ctx).Model(new(T)), conds).
+		Select("? AS group_key, COALESCE(SUM(?), 0) AS total", clause.Column{Name: groupCol}, clause.Column{Name: sumCol}).
+		Group(groupCol).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]V, len(rows))
+	for _, row := range rows {
+		out[row.GroupKey.String] += row.Total
+	}
+	return out, nil
+}
+
+// LockMode selects the row lock taken by FindWithLock.
+type LockMode int
+
+// Row lock modes.
+const (
+	// LockForUpdate takes an exclusive lock (SELECT ... FOR UPDATE).
+	LockForUpdate LockMode = iota
+	// LockForShare takes a shared lock (SELECT ... FOR SHARE).
+	LockForShare
+	// LockForUpdateSkipLocked takes an exclusive lock, skipping rows locked by others.
+	LockForUpdateSkipLocked
+)
+
+// clause maps the mode to GORM's locking clause.
+func (m LockMode) clause() clause.Locking {
+	switch m {
+	case LockForShare:
+		return clause.Locking{Strength: clause.LockingStrengthShare}
+	case LockForUpdateSkipLocked:
+		return clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked}
+	default:
+		return clause.Locking{Strength: clause.LockingStrengthUpdate}
+	}
+}
+
+// FindWithLock loads the T row with primary key id and locks it until the surrounding
+// transaction ends. uow must be bound to a transaction (see WithTx and RunInSerializable),
+// otherwise ErrNoActiveTransaction is returned. SQLite has no row locks and ignores the
+// clause; its transactions already serialize writers.
+func FindWithLock[T any](ctx context.Context, uow *UnitOfWork, id uint, mode LockMode) (*T, error) {
+	db := uow.conn(ctx)
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); !inTx {
+		return nil, ErrNoActiveTransaction
+	}
+	out := new(T)
+	if err := db.Clauses(mode.clause()).First(out, id).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// where applies inline conditions the same way GORM's finisher methods do.
+func where(db *gorm.DB, conds []any) *gorm.DB {
+	if len(conds) == 0 {
+		return db
+	}
+	return db.Where(conds[0], conds[1:]...)
+}
+
+// Hydrate completes partially loaded entities, such as rows read for a list view with only a
+// few columns, by fetching the rest by primary key in one WHERE pk IN (...) query. With
+// fields, given by Go field or column name, only those columns are fetched and set on
+// copies of partials, keeping their other values; without, every column is fetched.
+// The result follows the order of partials, which are not modified. T must have a single
+// primary key set on every partial; rows that no longer exist yield an error wrapping
+// ErrNotFound.
+func Hydrate[T any](ctx context.Context, uow *UnitOfWork, partials []T, fields ...string) ([]T, error) {
+	if len(partials) == 0 {
+		return nil, nil
+	}
+	db := uow.conn(ctx)
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	sch := stmt.Schema
+	if len(sch.PrimaryFields) != 1 {
+		return nil, fmt.Errorf("tracker: Hydrate: %s must have a single primary key", sch.Name)
+	}
+	pk := sch.PrimaryFields[0]
+	ids := make([]any, len(partials))
+	for i := range partials {
+		v, zero := pk.ValueOf(ctx, reflect.ValueOf(&partials[i]).Elem())
+		if zero {
+			return nil, fmt.Errorf("tracker: Hydrate: %s at index %d has no primary key value", sch.Name, i)
+		}
+		ids[i] = v
+	}
+	set := make([]*schema.Field, len(fields))
+	cols := []string{pk.DBName}
+	for i, name := range fields {
+		f := sch.LookUpField(name)
+		if f == nil || f.DBName == "" {
+			return nil, fmt.Errorf("tracker: Hydrate: %s has no column %s", sch.Name, name)
+		}
+		set[i] = f
+		cols = append(cols, f.DBName)
+	}
+
+	q := db.Model(new(T)).Where(clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: pk.DBName}, Values: ids})
+	if len(fields) > 0 {
+		q = q.Select(cols)
+	}
+	var rows []T
+	if err := q.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[any]reflect.Value, len(rows))
+	for i := range rows {
+		rv := reflect.ValueOf(&rows[i]).Elem()
+		id, _ := pk.ValueOf(ctx, rv)
+		byID[id] = rv
+	}
+
+	out := make([]T, len(partials))
+	for i, id := range ids {
+		row, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("tracker: Hydrate: %s %v: %w", sch.Name, id, ErrNotFound)
+		}
+		if len(fields) == 0 {
+			out[i] = row.Interface().(T)
+			continue
+		}
+		out[i] = partials[i]
+		rv := reflect.ValueOf(&out[i]).Elem()
+		for _, f := range set {
+			v, _ := f.ValueOf(ctx, row)
+			if err := f.Set(ctx, rv, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return out, nil
+}
+
+// QueryOption refines the query of FindAll, Count and Exists. The package provides Where,
+// OrderBy, Limit, Offset and Preload.
+type QueryOption func(*queryScope)
+
+// queryScope is the query QueryOptions refine.
+type queryScope struct {
+	db *gorm.DB
+}
+
+// whereOperators lists the comparison operators Where accepts.
+var whereOperators = map[string]bool{
+	"=": true, "<>": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+	"LIKE": true, "NOT LIKE": true, "IN": true, "NOT IN": true,
+}
+
+// Where filters rows by column op value, e.g. Where("status", "=", "NEW") or
+// Where("id", "IN", ids). op is one of =, <>, !=, <, <=, >, >=, LIKE, NOT LIKE, IN and
+// NOT IN; others make the query fail. The column name is quoted and value bound as a
+// parameter.
+func Where(column, op string, value any) QueryOption {
+	return func(q *queryScope) {
+		op := strings.ToUpper(strings.TrimSpace(op))
+		if !whereOperators[op] {
+			_ = q.db.AddError(fmt.Errorf("tracker: Where: unsupported operator %q", op))
+			return
+		}
+		q.db = q.db.Where(clause.Expr{SQL: "? " + op + " ?", Vars: []any{clause.Column{Name: column}, value}})
+	}
+}
+
+// OrderBy sorts rows by column, descending if desc. Options chain, so later OrderBys break
+// ties of earlier ones.
+func OrderBy(column string, desc bool) QueryOption {
+	return func(q *queryScope) {
+		q.db = q.db.Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: desc})
+	}
+}
+
+// Limit returns at most n rows.
+func Limit(n int) QueryOption {
+	return func(q *queryScope) { q.db = q.db.Limit(n) }
+}
+
+// Offset skips the first n rows, e.g. for pagination together with OrderBy and Limit.
+func Offset(n int) QueryOption {
+	return func(q *queryScope) { q.db = q.db.Offset(n) }
+}
+
+// Preload loads the assoc association of the rows read.
+func Preload(assoc string) QueryOption {
+	return func(q *queryScope) { q.db = q.db.Preload(assoc) }
+}
+
+// applyQuery refines db with opts.
+func applyQuery(db *gorm.DB, opts []QueryOption) *gorm.DB {
+	q := &queryScope{db: db}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q.db
+}
+
+// FindAll reads the rows selected by opts into out, a pointer to a slice of models, e.g.
+//
+//	uow.FindAll(ctx, &orders, tracker.Where("status", "=", "NEW"),
+//		tracker.OrderBy("created_at", true), tracker.Limit(20), tracker.Offset(40))
+func (r *UnitOfWork) FindAll(ctx context.Context, out any, opts ...QueryOption) error {
+	return applyQuery(r.conn(ctx), opts).Find(out).Error
+}
+
+// Count returns the number of model rows selected by opts; an empty table counts 0. Limit and
+// Offset are ignored, so the same opts give a page with FindAll and the total with Count.
+func (r *UnitOfWork) Count(ctx context.Context, model any, opts ...QueryOption) (int64, error) {
+	var n int64
+	err := applyQuery(r.conn(ctx).Model(model), opts).Limit(-1).Offset(-1).Count(&n).Error
+	return n, err
+}
+
+// Exists reports whether opts select any model row, with SELECT 1 ... LIMIT 1 rather than a
+// full count.
+func (r *UnitOfWork) Exists(ctx context.Context, model any, opts ...QueryOption) (bool, error) {
+	var one int
+	res := applyQuery(r.conn(ctx).Model(model), opts).Select("1").Limit(1).Scan(&one)
+	return res.RowsAffected > 0, res.Error
+}
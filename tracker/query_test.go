@@ -0,0 +1,148 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+)
+
+type queryOrder struct {
+	Status string
+	Region string
+	Total  int
+	ID     uint
+}
+
+// seedOrders commits a fixed set of orders and returns uow.
+func seedOrders(t *testing.T) *UnitOfWork {
+	t.Helper()
+	uow := newTestUoW(t, []any{&queryOrder{}})
+	for _, o := range []queryOrder{
+		{Status: "NEW", Region: "eu", Total: 10},
+		{Status: "NEW", Region: "us", Total: 20},
+		{Status: "PAID", Region: "eu", Total: 30},
+		{Status: "PAID", Region: "eu", Total: 40},
+	} {
+		uow.Add(&o)
+	}
+	mustCommit(t, uow)
+	return uow
+}
+
+func TestFindAllConditions(t *testing.T) {
+	ctx := context.Background()
+	uow := seedOrders(t)
+	tests := []struct {
+		name       string
+		opts       []QueryOption
+		wantTotals []int
+		wantErr    bool
+	}{
+		{name: "all", wantTotals: []int{10, 20, 30, 40}},
+		{name: "equals", opts: []QueryOption{Where("status", "=", "NEW")}, wantTotals: []int{10, 20}},
+		{name: "in", opts: []QueryOption{Where("total", "IN", []int{20, 40})}, wantTotals: []int{20, 40}},
+		{name: "page", opts: []QueryOption{OrderBy("total", true), Limit(2), Offset(1)}, wantTotals: []int{30, 20}},
+		{name: "unsupported operator", opts: []QueryOption{Where("status", "; DROP", "x")}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []queryOrder
+			err := uow.FindAll(ctx, &got, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FindAll = %v, wantErr %t", err, tt.wantErr)
+			}
+			totals := make([]int, len(got))
+			for i, o := range got {
+				totals[i] = o.Total
+			}
+			if !tt.wantErr && !slices.Equal(totals, tt.wantTotals) {
+				t.Fatalf("totals = %v, want %v", totals, tt.wantTotals)
+			}
+		})
+	}
+}
+
+func TestCountExists(t *testing.T) {
+	ctx := context.Background()
+	uow := seedOrders(t)
+	tests := []struct {
+		name      string
+		opts      []QueryOption
+		wantCount int64
+	}{
+		{name: "all", wantCount: 4},
+		{name: "equals", opts: []QueryOption{Where("status", "=", "NEW")}, wantCount: 2},
+		{name: "none", opts: []QueryOption{Where("status", "=", "VOID")}, wantCount: 0},
+		{name: "page ignored", opts: []QueryOption{Where("region", "=", "eu"), Limit(1), Offset(1)}, wantCount: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if n, err := uow.Count(ctx, &queryOrder{}, tt.opts...); err != nil || n != tt.wantCount {
+				t.Fatalf("Count = %d, %v, want %d", n, err, tt.wantCount)
+			}
+			if ok, err := uow.Exists(ctx, &queryOrder{}, tt.opts...); err != nil || ok != (tt.wantCount > 0) {
+				t.Fatalf("Exists = %t, %v, want %t", ok, err, tt.wantCount > 0)
+			}
+		})
+	}
+}
+
+func TestTakeFirstLast(t *testing.T) {
+	ctx := context.Background()
+	uow := seedOrders(t)
+	tests := []struct {
+		take    func() (*queryOrder, error)
+		wantErr error
+		name    string
+		want    int
+	}{
+		{name: "first", take: func() (*queryOrder, error) { return TakeFirst[queryOrder](ctx, uow) }, want: 10},
+		{name: "last", take: func() (*queryOrder, error) { return TakeLast[queryOrder](ctx, uow) }, want: 40},
+		{name: "last matching", take: func() (*queryOrder, error) { return TakeLast[queryOrder](ctx, uow, "status = ?", "NEW") }, want: 20},
+		{name: "none", take: func() (*queryOrder, error) { return TakeFirst[queryOrder](ctx, uow, "status = ?", "VOID") }, wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.take()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.Total != tt.want {
+				t.Fatalf("Total = %d, want %d", got.Total, tt.want)
+			}
+		})
+	}
+}
+
+func TestSumByGroup(t *testing.T) {
+	sums, err := SumByGroup[queryOrder, int](context.Background(), seedOrders(t), "status", "total")
+	if err != nil {
+		t.Fatalf("SumByGroup: %v", err)
+	}
+	if want := map[string]int{"NEW": 30, "PAID": 70}; !maps.Equal(sums, want) {
+		t.Fatalf("SumByGroup = %v, want %v", sums, want)
+	}
+}
+
+func TestCountDistinct(t *testing.T) {
+	ctx := context.Background()
+	uow := seedOrders(t)
+	tests := []struct {
+		name  string
+		conds []any
+		want  int64
+	}{
+		{name: "all regions", want: 2},
+		{name: "paid regions", conds: []any{"status = ?", "PAID"}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := CountDistinct[queryOrder](ctx, uow, "region", tt.conds...)
+			if err != nil || n != tt.want {
+				t.Fatalf("CountDistinct = %d, %v, want %d", n, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package tracker
+
+import (
+	"context"
+
+	"gorm.io/gorm/clause"
+)
+
+// Repository is a typed view of a UnitOfWork for the model T: it queues and reads *T
+// instead of any. Repositories of different models built on the same UnitOfWork share its
+// queue, so one Commit writes them all in a single transaction:
+//
+//	customers := tracker.NewRepository[Customer](uow)
+//	orders := tracker.NewRepository[Order](uow)
+//	customers.Add(&c)
+//	orders.Add(&o)
+//	err := uow.Commit(ctx)
+//
+// T is the struct type; entities are passed and returned as *T.
+type Repository[T any] struct {
+	uow *UnitOfWork
+}
+
+// NewRepository returns a Repository of T backed by uow.
+func NewRepository[T any](uow *UnitOfWork) *Repository[T] {
+	return &Repository[T]{uow: uow}
+}
+
+// UnitOfWork returns the UnitOfWork the repository queues its work on.
+func (r *Repository[T]) UnitOfWork() *UnitOfWork { return r.uow }
+
+// Add tracks entity to be created on commit.
+func (r *Repository[T]) Add(entity *T) { r.uow.Add(entity) }
+
+// Update tracks entity to be updated on commit.
+func (r *Repository[T]) Update(entity *T) { r.uow.Update(entity) }
+
+// Delete tracks entity to be deleted on commit.
+func (r *Repository[T]) Delete(entity *T) { r.uow.RegisterDelete(entity) }
+
+// FindByID fetches the T row with primary key id. A missing row returns ErrNotFound. id is
+// always compared as a value, never interpreted as SQL.
+func (r *Repository[T]) FindByID(ctx context.Context, id any) (*T, error) {
+	out := new(T)
+	if err := r.uow.conn(ctx).Where(clause.Eq{Column: clause.PrimaryColumn, Value: id}).First(out).Error; err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FindAll returns the T rows selected by opts, as UnitOfWork.FindAll does.
+func (r *Repository[T]) FindAll(ctx context.Context, opts ...QueryOption) ([]T, error) {
+	var out []T
+	if err := r.uow.FindAll(ctx, &out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Count returns the number of T rows selected by opts.
+func (r *Repository[T]) Count(ctx context.Context, opts ...QueryOption) (int64, error) {
+	return r.uow.Count(ctx, new(T), opts...)
+}
@@ -0,0 +1,66 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type repoCustomer struct {
+	Name string
+	ID   uint
+}
+
+type repoOrder struct {
+	Status         string
+	ID             uint
+	RepoCustomerID uint
+}
+
+func TestRepositorySharedCommit(t *testing.T) {
+	ctx := context.Background()
+	uow := newTestUoW(t, []any{&repoCustomer{}, &repoOrder{}})
+	customers := NewRepository[repoCustomer](uow)
+	orders := NewRepository[repoOrder](uow)
+
+	c := &repoCustomer{Name: "Ada"}
+	customers.Add(c)
+	orders.Add(&repoOrder{Status: "NEW"})
+	orders.Add(&repoOrder{Status: "PAID"})
+	mustCommit(t, uow)
+
+	got, err := customers.FindByID(ctx, c.ID)
+	if err != nil || got.Name != "Ada" {
+		t.Fatalf("FindByID = %+v, %v", got, err)
+	}
+	paid, err := orders.FindAll(ctx, Where("status", "=", "PAID"))
+	if err != nil || len(paid) != 1 {
+		t.Fatalf("FindAll = %+v, %v", paid, err)
+	}
+	if n, err := orders.Count(ctx); err != nil || n != 2 {
+		t.Fatalf("Count = %d, %v", n, err)
+	}
+}
+
+func TestRepositoryFindByIDDoesNotInjectSQL(t *testing.T) {
+	ctx := context.Background()
+	uow := newTestUoW(t, []any{&repoCustomer{}})
+	customers := NewRepository[repoCustomer](uow)
+	customers.Add(&repoCustomer{Name: "Ada"})
+	mustCommit(t, uow)
+
+	tests := []struct {
+		id   any
+		name string
+	}{
+		{name: "tautology", id: "1 OR 1=1"},
+		{name: "missing numeric id", id: 42},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := customers.FindByID(ctx, tt.id); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("FindByID(%v) = %+v, %v; want ErrNotFound", tt.id, got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package tracker
+
+import (
+	"context"
+	"sync"
+)
+
+// requestScopeKey is the context key of the map OncePerRequest records keys in.
+type requestScopeKey struct{}
+
+// onceEntry is the outcome of the first OncePerRequest call for a key.
+type onceEntry struct {
+	once sync.Once
+	err  error
+}
+
+// WithRequestScope returns a context in which OncePerRequest deduplicates calls. Call it once
+// per request, typically in HTTP middleware, and pass the result down:
+//
+//	next.ServeHTTP(w, r.WithContext(tracker.WithRequestScope(r.Context())))
+func WithRequestScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestScopeKey{}, &sync.Map{})
+}
+
+// OncePerRequest runs fn only the first time key is seen within the request scope of ctx,
+// e.g. so two services that both queue the same customer with Add do not insert it twice.
+// Later and concurrent calls with the same key wait for the first one and return its
+// error without running fn. Without a scope set by WithRequestScope, fn always runs.
+func OncePerRequest(ctx context.Context, key string, fn func() error) error {
+	seen, ok := ctx.Value(requestScopeKey{}).(*sync.Map)
+	if !ok {
+		return fn()
+	}
+	v, _ := seen.LoadOrStore(key, &onceEntry{})
+	e := v.(*onceEntry)
+	e.once.Do(func() { e.err = fn() })
+	return e.err
+}
@@ -0,0 +1,188 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+type savepointItem struct {
+	Name string
+	ID   uint
+}
+
+func TestSavepointRollback(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		queue     func(uow *UnitOfWork, id uint)
+		wantErr   error
+		name      string
+		wantCount int64
+	}{
+		{
+			name: "Do delete is rolled back",
+			queue: func(uow *UnitOfWork, id uint) {
+				uow.Do(func(tx Tx) error { return tx.Exec("DELETE FROM savepoint_items WHERE id = ?", id) })
+			},
+			wantCount: 2,
+		},
+		{
+			name:      "entity delete is rejected",
+			queue:     func(uow *UnitOfWork, id uint) { uow.RegisterDelete(&savepointItem{ID: id}) },
+			wantErr:   ErrEntityInSavepoint,
+			wantCount: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&savepointItem{}})
+			item := &savepointItem{Name: "kept"}
+			uow.Add(item)
+			mustCommit(t, uow)
+
+			uow.CreateSavepoint("sp1")
+			tt.queue(uow, item.ID)
+			uow.RollbackSavepoint("sp1")
+			uow.Add(&savepointItem{Name: "after"})
+			if err := uow.Commit(ctx); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Commit = %v, want %v", err, tt.wantErr)
+			}
+
+			var got savepointItem
+			if err := uow.First(ctx, &got, item.ID); err != nil {
+				t.Fatalf("First: %v (entity was deleted despite the rollback)", err)
+			}
+			if n, err := uow.Count(ctx, &savepointItem{}); err != nil || n != tt.wantCount {
+				t.Fatalf("Count = %d, %v, want %d", n, err, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestSavepointBlockClosedByClear(t *testing.T) {
+	uow := newTestUoW(t, []any{&savepointItem{}})
+	uow.CreateSavepoint("sp1")
+	uow.Add(&savepointItem{Name: "rejected"})
+	uow.Clear()
+	uow.Add(&savepointItem{Name: "accepted"})
+	mustCommit(t, uow)
+	if n, err := uow.Count(context.Background(), &savepointItem{}); err != nil || n != 1 {
+		t.Fatalf("Count = %d, %v, want 1", n, err)
+	}
+}
+
+func TestSavepointBlock(t *testing.T) {
+	errBoom := errors.New("boom")
+	insert := func(name string) Operation {
+		return func(tx Tx) error { return tx.Exec("INSERT INTO savepoint_items (name) VALUES (?)", name) }
+	}
+	tests := []struct {
+		wantErr   error
+		name      string
+		ops       []Operation
+		wantNames []string
+	}{
+		{
+			name:      "all ops succeed",
+			ops:       []Operation{insert("a"), insert("b")},
+			wantNames: []string{"a", "b", "outer"},
+		},
+		{
+			name:      "failing op rolls back the block only",
+			ops:       []Operation{insert("a"), func(Tx) error { return errBoom }, insert("b")},
+			wantErr:   errBoom,
+			wantNames: []string{"outer"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&savepointItem{}})
+			var gotErr error
+			uow.Savepoint("block", func(err error) { gotErr = err }, tt.ops...)
+			uow.Do(insert("outer"))
+			mustCommit(t, uow)
+
+			if !errors.Is(gotErr, tt.wantErr) {
+				t.Fatalf("onError got %v, want %v", gotErr, tt.wantErr)
+			}
+			var names []string
+			if err := uow.mustRoot().Model(&savepointItem{}).Order("name").Pluck("name", &names).Error; err != nil {
+				t.Fatalf("Pluck: %v", err)
+			}
+			if !slices.Equal(names, tt.wantNames) {
+				t.Fatalf("names = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestEntityInSavepointCommitPaths(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		commit func(uow *UnitOfWork) error
+		name   string
+	}{
+		{name: "Commit", commit: func(uow *UnitOfWork) error { return uow.Commit(ctx) }},
+		{name: "BatchCommit", commit: func(uow *UnitOfWork) error { return uow.BatchCommit(ctx, 10) }},
+		{name: "Transaction", commit: func(uow *UnitOfWork) error {
+			h, err := uow.Transaction(ctx)
+			if err != nil {
+				return err
+			}
+			h.CreateSavepoint("s1")
+			h.Add(&savepointItem{Name: "x"})
+			return h.Commit()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&savepointItem{}})
+			uow.CreateSavepoint("s1")
+			uow.Add(&savepointItem{Name: "x"})
+			if err := tt.commit(uow); !errors.Is(err, ErrEntityInSavepoint) {
+				t.Fatalf("commit = %v, want ErrEntityInSavepoint", err)
+			}
+			if n, err := uow.Count(ctx, &savepointItem{}); err != nil || n != 0 {
+				t.Fatalf("Count = %d, %v, want 0", n, err)
+			}
+		})
+	}
+}
+
+func TestBatchCommitSavepoints(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		queue   func(uow *UnitOfWork)
+		wantErr error
+		name    string
+	}{
+		{
+			name: "savepoint statements",
+			queue: func(uow *UnitOfWork) {
+				uow.CreateSavepoint("s1")
+				uow.Do(func(tx Tx) error { return tx.Exec("DELETE FROM savepoint_items") })
+				uow.RollbackSavepoint("s1")
+			},
+			wantErr: ErrSavepointInBatch,
+		},
+		{
+			name: "self-contained Savepoint block",
+			queue: func(uow *UnitOfWork) {
+				uow.Savepoint("s1", nil, func(tx Tx) error { return tx.Exec("DELETE FROM savepoint_items") })
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&savepointItem{}})
+			tt.queue(uow)
+			if err := uow.BatchCommit(ctx, 1); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("BatchCommit = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && uow.PendingCount() == 0 {
+				t.Fatal("rejected work was dropped from the queue")
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WithClause returns a scoped UnitOfWork whose statements, both reads and the operations
+// applied on commit, carry the given GORM clauses (e.g. clause.Locking, clause.OnConflict).
+// The scoped UoW starts with an empty queue; r is left untouched.
+func (r *UnitOfWork) WithClause(clauses ...clause.Expression) *UnitOfWork {
+	return r.scoped(r.mustRoot().Clauses(clauses...).Session(&gorm.Session{}))
+}
+
+// WithSchema returns a scoped UnitOfWork that runs SET LOCAL search_path at the start of
+// every transaction it commits, for schema-per-tenant PostgreSQL setups. The setting ends
+// with the transaction, so pooled connections are not affected; reads issued outside a
+// transaction keep the connection's default search_path. Commits on other dialects fail
+// with ErrUnsupportedDialect.
+func (r *UnitOfWork) WithSchema(schema string) *UnitOfWork {
+	s := r.scoped(r.mustRoot())
+	s.opts.addOnBegin(func(tx *gorm.DB) error {
+		if name := tx.Dialector.Name(); name != "postgres" {
+			return fmt.Errorf("%w: WithSchema on %s", ErrUnsupportedDialect, name)
+		}
+		return tx.Exec("SET LOCAL search_path TO " + tx.Statement.Quote(schema)).Error
+	})
+	return s
+}
+
+// WithTx returns a scoped UnitOfWork bound to an open transaction, such as the Tx handed to
+// WrapTransaction or lent by a TransactionPool, so UoW reads and helpers like FindWithLock
+// run inside it. Committing the scoped UoW applies its queue within that transaction.
+// Tx implementations not created by this package yield an unbound copy of r.
+func (r *UnitOfWork) WithTx(tx Tx) *UnitOfWork {
+	if g, ok := tx.(interface{ gormDB() *gorm.DB }); ok {
+		return r.scoped(g.gormDB())
+	}
+	return r.scoped(r.mustRoot())
+}
+
+// WithDistinct returns a scoped UnitOfWork whose reads select DISTINCT values of cols, e.g.
+// Find[Order](ctx, uow.WithDistinct("status"), ...) yields one row per status with only that
+// column populated. With no cols, whole rows are deduplicated (SELECT DISTINCT *).
+// The column selection also restricts writes, so use the scoped UoW for reads only.
+func (r *UnitOfWork) WithDistinct(cols ...string) *UnitOfWork {
+	return r.scoped(distinct(r.mustRoot(), cols).Session(&gorm.Session{}))
+}
+
+// distinct selects DISTINCT cols, or whole distinct rows when cols is empty, which GORM's
+// Distinct does not do on its own.
+func distinct(db *gorm.DB, cols []string) *gorm.DB {
+	if len(cols) == 0 {
+		return db.Select("DISTINCT ?.*", clause.Table{Name: clause.CurrentTable})
+	}
+	args := make([]any, len(cols))
+	for i, c := range cols {
+		args[i] = c
+	}
+	return db.Distinct(args...)
+}
+
+// WithSavepoint returns a scoped UnitOfWork whose commits run inside SAVEPOINT name. Bound to
+// an open transaction (see WithTx), a failed commit rolls back to the savepoint only, so the
+// surrounding transaction keeps its other changes and can still commit; a successful one
+// releases it. Unbound, the savepoint is taken inside the transaction Commit opens.
+func (r *UnitOfWork) WithSavepoint(name string) *UnitOfWork {
+	s := r.scoped(r.mustRoot())
+	s.opts.savepoint = name
+	return s
+}
+
+// PreloadWithOrder returns a scoped UnitOfWork whose reads preload assoc sorted by orderExpr,
+// e.g. uow.PreloadWithOrder("Orders", "amount ASC").PreloadFirst(ctx, &c, id). Naming assoc
+// again in PreloadFirst keeps the ordering.
+func (r *UnitOfWork) PreloadWithOrder(assoc, orderExpr string) *UnitOfWork {
+	return r.scoped(r.mustRoot().Preload(assoc, func(db *gorm.DB) *gorm.DB {
+		return db.Order(orderExpr)
+	}).Session(&gorm.Session{}))
+}
+
+// Preload returns a scoped UnitOfWork whose reads also load assoc, filtered by conds as in
+// GORM's Preload, e.g. uow.Preload("Orders", "status = ?", "NEW").Preload("Invoices").
+// Calls chain, each adding one association; naming assoc again in PreloadFirst keeps its
+// conditions.
+func (r *UnitOfWork) Preload(assoc string, conds ...any) *UnitOfWork {
+	return r.scoped(r.mustRoot().Preload(assoc, conds...).Session(&gorm.Session{}))
+}
+
+// WithNoGlobalScopes returns a scoped UnitOfWork without the conditions and clauses r was
+// scoped with (Tenancy, WithClause, WithDistinct, ...), for administrative access to every
+// row. It also includes soft-deleted rows and hard-deletes. A transaction bound with WithTx
+// is kept.
+func (r *UnitOfWork) WithNoGlobalScopes() *UnitOfWork {
+	return r.scoped(r.mustRoot().Session(&gorm.Session{NewDB: true}).Unscoped().Session(&gorm.Session{}))
+}
+
+// AsReadOnly returns a scoped UnitOfWork for code that must only read, such as a reporting
+// service. Reads work as usual, but Add, Update, RegisterDelete and Do (with its variants)
+// queue nothing and record ErrReadOnly, and Commit, SaveChanges and BatchCommit fail with it. Helpers that write
+// immediately, outside the queue, such as WrapTransaction, CompareAndSwap, MultiInsert and
+// DropTable, fail with ErrReadOnly as well.
+func (r *UnitOfWork) AsReadOnly() *UnitOfWork {
+	s := r.scoped(r.mustRoot())
+	s.opts.readOnly = true
+	return s
+}
+
+// WithCancelOnError returns a context derived from ctx, a scoped UnitOfWork linked to it and
+// the function canceling it, so a handler can abort the UoW's work when something fails
+// midway. Once the context is canceled, whether by cancel or by ctx, the UoW queues
+// nothing more, and its next SaveChanges or Commit clears the queue and returns the
+// context's error, such as context.Canceled, without executing any SQL.
+func (r *UnitOfWork) WithCancelOnError(ctx context.Context) (context.Context, *UnitOfWork, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	s := r.scoped(r.mustRoot())
+	s.opts.cancelOn = ctx
+	return ctx, s, cancel
+}
+
+// rejectsWork reports whether queueing methods must drop new work: on read-only UoWs, where
+// it records ErrReadOnly for the next commit, and once the context linked by
+// WithCancelOnError is canceled.
+func (r *UnitOfWork) rejectsWork() bool {
+	if r.opts.readOnly {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.queueErr == nil {
+			r.queueErr = ErrReadOnly
+		}
+		return true
+	}
+	return r.canceled() != nil
+}
+
+// writable returns ErrReadOnly on a read-only UnitOfWork, for helpers that write immediately.
+func (r *UnitOfWork) writable() error {
+	if r.opts.readOnly {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// canceled returns the error of the context linked by WithCancelOnError, if any.
+func (r *UnitOfWork) canceled() error {
+	if r.opts.cancelOn == nil {
+		return nil
+	}
+	return r.opts.cancelOn.Err()
+}
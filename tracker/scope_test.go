@@ -0,0 +1,115 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type readOnlyCustomer struct {
+	Name string
+	ID   uint
+}
+
+func TestAsReadOnlyRejectsWrites(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		write func(ro *UnitOfWork, id uint) error
+		name  string
+	}{
+		{name: "Add", write: func(ro *UnitOfWork, _ uint) error {
+			ro.Add(&readOnlyCustomer{Name: "Bob"})
+			return ro.Commit(ctx)
+		}},
+		{name: "Update", write: func(ro *UnitOfWork, id uint) error {
+			ro.Update(&readOnlyCustomer{ID: id, Name: "Bob"})
+			return ro.SaveChanges(ctx)
+		}},
+		{name: "RegisterDelete", write: func(ro *UnitOfWork, id uint) error {
+			ro.RegisterDelete(&readOnlyCustomer{ID: id})
+			return ro.Commit(ctx)
+		}},
+		{name: "Do", write: func(ro *UnitOfWork, _ uint) error {
+			ro.Do(func(tx Tx) error { return tx.Exec("DELETE FROM read_only_customers") })
+			return ro.Commit(ctx)
+		}},
+		{name: "WrapTransaction", write: func(ro *UnitOfWork, _ uint) error {
+			return ro.WrapTransaction(ctx, func(tx Tx) error { return tx.Exec("DELETE FROM read_only_customers") })
+		}},
+		{name: "Transactional", write: func(ro *UnitOfWork, _ uint) error {
+			_, err := Transactional(ctx, ro, func(tx Tx) (int, error) { return 0, tx.Exec("DELETE FROM read_only_customers") })
+			return err
+		}},
+		{name: "CompareAndSwap", write: func(ro *UnitOfWork, id uint) error {
+			_, err := CompareAndSwap[readOnlyCustomer](ctx, ro, id, "name", "Ada", "Bob")
+			return err
+		}},
+		{name: "BatchDelete", write: func(ro *UnitOfWork, id uint) error {
+			_, err := BatchDelete[readOnlyCustomer](ctx, ro, []uint{id})
+			return err
+		}},
+		{name: "MultiInsert", write: func(ro *UnitOfWork, _ uint) error {
+			_, err := MultiInsert(ctx, ro, "read_only_customers", []map[string]any{{"name": "Bob"}})
+			return err
+		}},
+		{name: "UpdateFromJoin", write: func(ro *UnitOfWork, _ uint) error {
+			_, err := UpdateFromJoin[readOnlyCustomer, readOnlyCustomer](ctx, ro, "1 = 1", map[string]any{"name": "Bob"}, "1 = 1")
+			return err
+		}},
+		{name: "DropTable", write: func(ro *UnitOfWork, _ uint) error { return ro.DropTable(ctx, &readOnlyCustomer{}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&readOnlyCustomer{}})
+			c := &readOnlyCustomer{Name: "Ada"}
+			uow.Add(c)
+			mustCommit(t, uow)
+
+			ro := uow.AsReadOnly()
+			if err := tt.write(ro, c.ID); !errors.Is(err, ErrReadOnly) {
+				t.Fatalf("err = %v, want ErrReadOnly", err)
+			}
+			if ro.HasPending() {
+				t.Fatal("read-only UnitOfWork queued work")
+			}
+
+			var got readOnlyCustomer
+			if err := ro.First(ctx, &got, c.ID); err != nil {
+				t.Fatalf("First: %v", err)
+			}
+			if got.Name != "Ada" {
+				t.Fatalf("Name = %q, want Ada", got.Name)
+			}
+			if n, err := ro.Count(ctx, &readOnlyCustomer{}); err != nil || n != 1 {
+				t.Fatalf("Count = %d, %v, want 1", n, err)
+			}
+		})
+	}
+}
+
+func TestAsReadOnlyRecordsDroppedWork(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		commit func(ro *UnitOfWork) error
+		name   string
+	}{
+		{name: "Commit", commit: func(ro *UnitOfWork) error { return ro.Commit(ctx) }},
+		{name: "BatchCommit", commit: func(ro *UnitOfWork) error { return ro.BatchCommit(ctx, 10) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&readOnlyCustomer{}})
+			ro := uow.AsReadOnly()
+			ro.Add(&readOnlyCustomer{Name: "Bob"})
+			if err := ro.queueError(); !errors.Is(err, ErrReadOnly) {
+				t.Fatalf("queueError = %v, want ErrReadOnly", err)
+			}
+			if err := tt.commit(ro); !errors.Is(err, ErrReadOnly) {
+				t.Fatalf("err = %v, want ErrReadOnly", err)
+			}
+			if n, err := uow.Count(ctx, &readOnlyCustomer{}); err != nil || n != 0 {
+				t.Fatalf("Count = %d, %v, want 0", n, err)
+			}
+		})
+	}
+}
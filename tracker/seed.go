@@ -0,0 +1,121 @@
+package tracker
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+
+	"gorm.io/gorm"
+)
+
+// SeedOnce runs seederFn only if the T table is empty. The emptiness check and the seed
+// share one serializable transaction, so concurrent callers seed at most once.
+// Work queued by seederFn on the given UoW is committed with that transaction.
+func SeedOnce[T any](ctx context.Context, uow *UnitOfWork, seederFn func(*UnitOfWork) error) error {
+	return uow.RunInSerializable(ctx, func(scoped *UnitOfWork) error {
+		n, err := Count[T](ctx, scoped)
+		if err != nil || n > 0 {
+			return err
+		}
+		return seederFn(scoped)
+	})
+}
+
+// WhenEmpty calls fn only if the T table is empty, for bootstrap logic that is not a write
+// through the UoW, such as provisioning an external resource. The check runs in a
+// serializable transaction that stays open while fn runs, so concurrent callers see a
+// consistent answer; fn is not given that transaction, and on SQLite it must not write
+// through another connection, which would wait on the transaction's locks. To seed the
+// table itself, use SeedOnce, which commits the seed with the check. fn may be called again
+// if the transaction is retried after a serialization failure.
+func WhenEmpty[T any](ctx context.Context, uow *UnitOfWork, fn func() error) error {
+	return uow.RunInSerializable(ctx, func(scoped *UnitOfWork) error {
+		n, err := Count[T](ctx, scoped)
+		if err != nil || n > 0 {
+			return err
+		}
+		return fn()
+	})
+}
+
+// SeedLoader inserts fixture documents. A document is a JSON object mapping table names to
+// arrays of rows keyed by column name, e.g. {"customers": [{"name": "Ada"}]}.
+// Tables are loaded in document order, so list parents before children.
+type SeedLoader struct {
+	uow *UnitOfWork
+}
+
+// NewSeedLoader creates a SeedLoader writing through uow.
+func NewSeedLoader(uow *UnitOfWork) *SeedLoader {
+	return &SeedLoader{uow: uow}
+}
+
+// Load inserts every row of the document read from rd in a single transaction.
+func (r *SeedLoader) Load(ctx context.Context, rd io.Reader) error {
+	if err := r.uow.writable(); err != nil {
+		return err
+	}
+	return r.uow.conn(ctx).Transaction(func(tx *gorm.DB) error { return loadSeed(tx, rd) })
+}
+
+// LoadFixturesFromEmbed loads every file of fsys whose path matches pattern (path.Match
+// syntax, e.g. "testdata/*.json") through the SeedLoader, in lexical order and in a
+// single transaction.
+func LoadFixturesFromEmbed(ctx context.Context, uow *UnitOfWork, fsys embed.FS, pattern string) error {
+	if err := uow.writable(); err != nil {
+		return err
+	}
+	return uow.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			if ok, matchErr := path.Match(pattern, name); matchErr != nil || !ok {
+				return matchErr
+			}
+			f, err := fsys.Open(name)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			if err = loadSeed(tx, f); err != nil {
+				return fmt.Errorf("tracker: fixture %s: %w", name, err)
+			}
+			return nil
+		})
+	})
+}
+
+// loadSeed decodes a seed document table by table, preserving key order, and inserts the rows.
+func loadSeed(tx *gorm.DB, rd io.Reader) error {
+	dec := json.NewDecoder(rd)
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return errors.New("tracker: seed document must be a JSON object")
+	}
+	for dec.More() {
+		if tok, err = dec.Token(); err != nil {
+			return err
+		}
+		table, _ := tok.(string)
+		var rows []map[string]any
+		if err = dec.Decode(&rows); err != nil {
+			return fmt.Errorf("tracker: seed table %q: %w", table, err)
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		if err = tx.Table(table).Create(&rows).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
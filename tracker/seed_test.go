@@ -0,0 +1,107 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+type seedRow struct {
+	Name string
+	ID   uint
+}
+
+func TestWhenEmpty(t *testing.T) {
+	ctx := context.Background()
+	uow := newTestUoW(t, []any{&seedRow{}})
+	uow.Add(&seedRow{Name: "existing"})
+	mustCommit(t, uow)
+
+	errFn := errors.New("fn failed")
+	tests := []struct {
+		fnErr     error
+		name      string
+		truncate  bool
+		wantCalls int
+	}{
+		{name: "filled table", wantCalls: 0},
+		{name: "truncated table", truncate: true, wantCalls: 1},
+		{name: "fn error", fnErr: errFn, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.truncate {
+				if err := uow.mustRoot().Exec("DELETE FROM seed_rows").Error; err != nil {
+					t.Fatalf("truncate: %v", err)
+				}
+			}
+			calls := 0
+			err := WhenEmpty[seedRow](ctx, uow, func() error {
+				calls++
+				return tt.fnErr
+			})
+			if !errors.Is(err, tt.fnErr) || calls != tt.wantCalls {
+				t.Fatalf("WhenEmpty = %v after %d calls, want %v after %d", err, calls, tt.fnErr, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestSeedOnce(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		prefill int
+		want    int64
+		calls   int
+	}{
+		{name: "empty table is seeded", prefill: 0, want: 2, calls: 1},
+		{name: "filled table is left alone", prefill: 1, want: 1, calls: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&seedRow{}})
+			for range tt.prefill {
+				uow.Add(&seedRow{Name: "existing"})
+			}
+			mustCommit(t, uow)
+
+			calls := 0
+			err := SeedOnce[seedRow](ctx, uow, func(scoped *UnitOfWork) error {
+				calls++
+				scoped.Add(&seedRow{Name: "a"})
+				scoped.Add(&seedRow{Name: "b"})
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("SeedOnce: %v", err)
+			}
+			n, err := Count[seedRow](ctx, uow)
+			if err != nil || n != tt.want || calls != tt.calls {
+				t.Fatalf("rows = %d (%v), calls = %d; want %d rows, %d calls", n, err, calls, tt.want, tt.calls)
+			}
+		})
+	}
+}
+
+func TestSeedOnceConcurrent(t *testing.T) {
+	ctx := context.Background()
+	uow := newTestUoW(t, []any{&seedRow{}})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			err := SeedOnce[seedRow](ctx, uow, func(scoped *UnitOfWork) error {
+				scoped.Add(&seedRow{Name: "seed"})
+				return nil
+			})
+			if err != nil && !isSerializationFailure(err) {
+				t.Errorf("SeedOnce: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+	if n, err := Count[seedRow](ctx, uow); err != nil || n != 1 {
+		t.Fatalf("rows = %d (%v), want 1", n, err)
+	}
+}
@@ -0,0 +1,49 @@
+package tracker
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// serializableRetries is how many times RunInSerializable retries after a serialization failure.
+	serializableRetries = 3
+	// serializableBackoff is the initial delay between retries; it doubles on every attempt.
+	serializableBackoff = 10 * time.Millisecond
+)
+
+// RunInSerializable runs fn against a UoW scoped to a transaction at sql.LevelSerializable
+// and commits whatever fn queued on it. Reads made through the scoped UoW happen inside
+// the same transaction. On a serialization failure the whole block, including fn, is
+// retried up to 3 times with exponential backoff.
+func (r *UnitOfWork) RunInSerializable(ctx context.Context, fn func(*UnitOfWork) error) error {
+	backoff := serializableBackoff
+	for attempt := 0; ; attempt++ {
+		err := r.runSerializable(ctx, fn)
+		if err == nil || !isSerializationFailure(err) || attempt == serializableRetries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// runSerializable performs a single RunInSerializable attempt.
+func (r *UnitOfWork) runSerializable(ctx context.Context, fn func(*UnitOfWork) error) error {
+	scoped := r.scoped(nil)
+	txErr := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		scoped.root = tx
+		if err := fn(scoped); err != nil {
+			return err
+		}
+		return scoped.apply(tx, scoped.snapshot())
+	}, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	return scoped.finish(ctx, scoped.snapshot(), txErr)
+}
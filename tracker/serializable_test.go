@@ -0,0 +1,49 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type ticket struct {
+	Owner string
+	ID    uint
+}
+
+func TestRunInSerializable(t *testing.T) {
+	errAbort := errors.New("abort")
+	tests := []struct {
+		wantErr   error
+		name      string
+		wantCount int64
+	}{
+		{name: "commits queued work", wantCount: 2},
+		{name: "fn error rolls back", wantErr: errAbort, wantCount: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			uow := newTestUoW(t, []any{&ticket{}})
+			uow.Add(&ticket{Owner: "seed"})
+			mustCommit(t, uow)
+
+			err := uow.RunInSerializable(ctx, func(s *UnitOfWork) error {
+				n, err := s.Count(ctx, &ticket{})
+				if err != nil {
+					return err
+				}
+				if n == 1 {
+					s.Add(&ticket{Owner: "second"})
+				}
+				return tt.wantErr
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RunInSerializable = %v, want %v", err, tt.wantErr)
+			}
+			if n, err := uow.Count(ctx, &ticket{}); err != nil || n != tt.wantCount {
+				t.Fatalf("Count = %d, %v, want %d", n, err, tt.wantCount)
+			}
+		})
+	}
+}
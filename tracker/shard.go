@@ -0,0 +1,170 @@
+package tracker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// ShardCommitErrors is returned by Commit on a sharded UnitOfWork when some shards failed,
+// keyed by shard index. Shards missing from the map committed their part.
+type ShardCommitErrors map[int]error
+
+// Error lists the failed shards in index order.
+func (e ShardCommitErrors) Error() string {
+	var b strings.Builder
+	b.WriteString("tracker: sharded commit failed:")
+	for _, i := range e.shards() {
+		fmt.Fprintf(&b, " shard %d: %v;", i, e[i])
+	}
+	return strings.TrimSuffix(b.String(), ";")
+}
+
+// Unwrap returns the shard errors in index order, for errors.Is and errors.As.
+func (e ShardCommitErrors) Unwrap() []error {
+	out := make([]error, 0, len(e))
+	for _, i := range e.shards() {
+		out = append(out, e[i])
+	}
+	return out
+}
+
+// shards returns the failed shard indexes in ascending order.
+func (e ShardCommitErrors) shards() []int {
+	out := make([]int, 0, len(e))
+	for i := range e {
+		out = append(out, i)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// NewSharded creates a UnitOfWork spread over several databases. On commit, every queued
+// entity is written to the shard whose index shardFn returns for it, and the shards commit
+// concurrently, each in its own transaction. There is no cross-shard atomicity: if some
+// shards fail, the others still commit, the work routed to them is dequeued, and Commit
+// returns ShardCommitErrors. After-commit callbacks and domain events fire only when every
+// shard succeeded. Custom operations cannot be routed and make Commit fail. Reads go to
+// the first shard; use Shard for the others. It fails if any shard cannot be opened.
+func NewSharded(shards []*sql.DB, shardFn func(entity any) int, opts ...Option) (*UnitOfWork, error) {
+	o := newOptions(opts)
+	uow := &UnitOfWork{opts: o, shardFn: shardFn}
+	for i, db := range shards {
+		s, err := newUnitOfWork(db, o)
+		if err != nil {
+			return nil, fmt.Errorf("tracker: shard %d: %w", i, err)
+		}
+		uow.shards = append(uow.shards, s)
+	}
+	if len(uow.shards) > 0 {
+		uow.root = uow.shards[0].root
+	}
+	return uow, nil
+}
+
+// Shard returns a UnitOfWork on the i-th shard of a UnitOfWork created by NewSharded,
+// or nil if there is no such shard.
+func (r *UnitOfWork) Shard(i int) *UnitOfWork {
+	if i < 0 || i >= len(r.shards) {
+		return nil
+	}
+	return r.shards[i]
+}
+
+// commitSharded is Commit for UoWs created by NewSharded.
+func (r *UnitOfWork) commitSharded(ctx context.Context) error {
+	c := r.snapshot()
+	if len(c.ops) > 0 {
+		return errors.New("tracker: custom operations cannot be routed to a shard")
+	}
+	route := func(items []any) ([]int, error) {
+		out := make([]int, len(items))
+		for i, e := range items {
+			out[i] = r.shardFn(entityOf(e))
+			if out[i] < 0 || out[i] >= len(r.shards) {
+				return nil, fmt.Errorf("tracker: shard %d out of range for %T", out[i], entityOf(e))
+			}
+		}
+		return out, nil
+	}
+	creates, err := route(c.creates)
+	if err != nil {
+		return err
+	}
+	updates, err := route(c.updates)
+	if err != nil {
+		return err
+	}
+	deletes, err := route(c.deletes)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = ShardCommitErrors{}
+	)
+	for i, shard := range r.shards {
+		part := c
+		part.creates = pick(c.creates, creates, i)
+		part.updates = pick(c.updates, updates, i)
+		part.deletes = pick(c.deletes, deletes, i)
+		if len(part.creates)+len(part.updates)+len(part.deletes) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := shard.transaction(ctx, func(tx *gorm.DB) error { return r.apply(tx, part) })
+			if err != nil {
+				mu.Lock()
+				errs[i] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) == 0 {
+		return r.finish(ctx, c, nil)
+	}
+	r.mu.Lock()
+	r.toCreate = keepFailed(r.toCreate, creates, errs)
+	r.toUpdate = keepFailed(r.toUpdate, updates, errs)
+	r.toDelete = keepFailed(r.toDelete, deletes, errs)
+	r.mu.Unlock()
+	return r.finish(ctx, c, errs)
+}
+
+// pick returns the items routed to shard.
+func pick(items []any, routes []int, shard int) []any {
+	var out []any
+	for i, e := range items {
+		if routes[i] == shard {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// keepFailed drops the committed items from the front of queue, which holds the routed
+// items followed by any work queued during the commit.
+func keepFailed(queue []any, routes []int, errs ShardCommitErrors) []any {
+	out := queue[:0:0]
+	for i, e := range queue {
+		if i < len(routes) {
+			if _, failed := errs[routes[i]]; !failed {
+				continue
+			}
+		}
+		out = append(out, e)
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+package tracker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+)
+
+type shardedOrder struct {
+	Item string
+	ID   uint
+}
+
+// newShards opens n fresh SQLite databases, migrating shardedOrder on those not in skip.
+func newShards(t *testing.T, n int, skip ...int) []*sql.DB {
+	t.Helper()
+	dbs := make([]*sql.DB, n)
+	for i := range dbs {
+		dbs[i] = newTestDB(t)
+		if slices.Contains(skip, i) {
+			continue
+		}
+		if _, err := NewWithOptions(dbs[i], WithAutoMigrate(&shardedOrder{})); err != nil {
+			t.Fatalf("migrate shard %d: %v", i, err)
+		}
+	}
+	return dbs
+}
+
+func byID(e any) int { return int(e.(*shardedOrder).ID % 3) }
+
+func TestNewShardedRouting(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		wantErr    map[int]bool
+		name       string
+		broken     []int
+		wantCounts []int64
+	}{
+		{name: "all shards", wantCounts: []int64{3, 3, 3}},
+		{name: "shard 1 fails", broken: []int{1}, wantErr: map[int]bool{1: true}, wantCounts: []int64{3, -1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbs := newShards(t, 3, tt.broken...)
+			uow, err := NewSharded(dbs, byID)
+			if err != nil {
+				t.Fatalf("NewSharded: %v", err)
+			}
+			for id := uint(1); id <= 9; id++ {
+				uow.Add(&shardedOrder{ID: id, Item: "x"})
+			}
+			err = uow.Commit(ctx)
+			var shardErrs ShardCommitErrors
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Commit: %v", err)
+				}
+			} else if !errors.As(err, &shardErrs) || len(shardErrs) != len(tt.wantErr) {
+				t.Fatalf("Commit = %v, want ShardCommitErrors for %v", err, tt.wantErr)
+			}
+			for i := range shardErrs {
+				if !tt.wantErr[i] {
+					t.Fatalf("shard %d failed unexpectedly: %v", i, shardErrs[i])
+				}
+			}
+			if want := 3 * len(tt.wantErr); uow.PendingCount() != want {
+				t.Fatalf("PendingCount = %d, want %d failed items still queued", uow.PendingCount(), want)
+			}
+			for i, want := range tt.wantCounts {
+				if want < 0 {
+					continue
+				}
+				var rows []shardedOrder
+				if err := uow.Shard(i).FindAll(ctx, &rows); err != nil {
+					t.Fatalf("shard %d: %v", i, err)
+				}
+				if int64(len(rows)) != want {
+					t.Fatalf("shard %d has %d rows, want %d", i, len(rows), want)
+				}
+				for _, r := range rows {
+					if int(r.ID%3) != i {
+						t.Fatalf("row %d on shard %d, want shard %d", r.ID, i, r.ID%3)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNewShardedOpenError(t *testing.T) {
+	dbs := newShards(t, 2)
+	_ = dbs[1].Close()
+	if uow, err := NewSharded(dbs, byID, WithAutoMigrate(&shardedOrder{})); err == nil || uow != nil {
+		t.Fatalf("NewSharded = %v, %v, want an error for the closed shard", uow, err)
+	}
+}
@@ -0,0 +1,178 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ConstraintViolation is a queued entity field that would break a database constraint.
+type ConstraintViolation struct {
+	Value any
+	// Model and Field name the Go struct and field, Constraint is "not null", "size" or "unique".
+	Model      string
+	Field      string
+	Constraint string
+}
+
+// ConstraintViolations is returned by Commit in strict mode when queued entities break
+// constraints declared in their gorm tags. No SQL other than the uniqueness checks is sent.
+type ConstraintViolations []ConstraintViolation
+
+// Error lists the violations.
+func (v ConstraintViolations) Error() string {
+	msgs := make([]string, len(v))
+	for i, cv := range v {
+		msgs[i] = fmt.Sprintf("%s.%s violates %s (value %v)", cv.Model, cv.Field, cv.Constraint, cv.Value)
+	}
+	return "tracker: constraint violations: " + strings.Join(msgs, "; ")
+}
+
+// WithStrictMode returns a scoped UnitOfWork whose commits validate created and updated
+// entities against the constraints in their gorm tags before opening the transaction: not
+// null fields must be non-zero, sized string fields must fit, and unique fields and unique
+// indexes must not match another row (checked with a preliminary SELECT, so concurrent
+// writers can still collide). Violations are returned as ConstraintViolations.
+func (r *UnitOfWork) WithStrictMode() *UnitOfWork {
+	s := r.scoped(r.mustRoot())
+	s.opts.strict = true
+	return s
+}
+
+// checkConstraints validates c's creates and updates for strict mode. The uniqueness
+// queries are only issued once the tag checks pass.
+func (r *UnitOfWork) checkConstraints(ctx context.Context, c changes) error {
+	db := r.conn(ctx)
+	type target struct {
+		sch      *schema.Schema
+		rv       reflect.Value
+		isUpdate bool
+	}
+	var targets []target
+	collect := func(entities []any, isUpdate bool) error {
+		for _, e := range entities {
+			e = entityOf(e)
+			stmt := &gorm.Statement{DB: db}
+			if err := stmt.Parse(e); err != nil {
+				return err
+			}
+			for _, rv := range structValues(reflect.ValueOf(e)) {
+				targets = append(targets, target{sch: stmt.Schema, rv: rv, isUpdate: isUpdate})
+			}
+		}
+		return nil
+	}
+	if err := collect(c.creates, false); err != nil {
+		return err
+	}
+	if err := collect(c.updates, true); err != nil {
+		return err
+	}
+
+	var out ConstraintViolations
+	for _, t := range targets {
+		out = append(out, fieldViolations(ctx, t.sch, t.rv)...)
+	}
+	if len(out) > 0 {
+		return out
+	}
+	for _, t := range targets {
+		vs, err := uniqueViolations(db, t.sch, t.rv, t.isUpdate)
+		if err != nil {
+			return err
+		}
+		out = append(out, vs...)
+	}
+	if len(out) > 0 {
+		return out
+	}
+	return nil
+}
+
+// structValues returns the structs held by v, a struct, pointer or slice of them.
+func structValues(v reflect.Value) []reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return structValues(v.Elem())
+	case reflect.Slice, reflect.Array:
+		var out []reflect.Value
+		for i := range v.Len() {
+			out = append(out, structValues(v.Index(i))...)
+		}
+		return out
+	case reflect.Struct:
+		return []reflect.Value{v}
+	}
+	return nil
+}
+
+// fieldViolations checks the not null and size constraints of one entity.
+func fieldViolations(ctx context.Context, sch *schema.Schema, rv reflect.Value) []ConstraintViolation {
+	var out []ConstraintViolation
+	for _, f := range sch.Fields {
+		if f.DBName == "" {
+			continue
+		}
+		v, zero := f.ValueOf(ctx, rv)
+		if f.NotNull && zero && !f.HasDefaultValue && !f.AutoIncrement {
+			out = append(out, ConstraintViolation{Model: sch.Name, Field: f.Name, Constraint: "not null", Value: v})
+		}
+		if s, ok := v.(string); ok && f.Size > 0 && utf8.RuneCountInString(s) > f.Size {
+			out = append(out, ConstraintViolation{Model: sch.Name, Field: f.Name, Constraint: "size", Value: v})
+		}
+	}
+	return out
+}
+
+// uniqueViolations looks for other rows sharing one entity's unique fields or unique index
+// columns. Rows with the entity's own primary key do not count, so updates do not conflict
+// with themselves.
+func uniqueViolations(db *gorm.DB, sch *schema.Schema, rv reflect.Value, isUpdate bool) ([]ConstraintViolation, error) {
+	ctx := db.Statement.Context
+	var uniques [][]*schema.Field
+	for _, f := range sch.Fields {
+		if f.Unique && !f.PrimaryKey {
+			uniques = append(uniques, []*schema.Field{f})
+		}
+	}
+	for _, idx := range sch.ParseIndexes() {
+		if idx.Class != "UNIQUE" {
+			continue
+		}
+		fields := make([]*schema.Field, len(idx.Fields))
+		for i, o := range idx.Fields {
+			fields[i] = o.Field
+		}
+		uniques = append(uniques, fields)
+	}
+
+	var out []ConstraintViolation
+	for _, fields := range uniques {
+		q := db.Session(&gorm.Session{NewDB: true}).Model(reflect.New(sch.ModelType).Interface())
+		for _, f := range fields {
+			v, _ := f.ValueOf(ctx, rv)
+			q = q.Where(clause.Eq{Column: clause.Column{Name: f.DBName}, Value: v})
+		}
+		if isUpdate {
+			for _, pk := range sch.PrimaryFields {
+				v, _ := pk.ValueOf(ctx, rv)
+				q = q.Where(clause.Neq{Column: clause.Column{Name: pk.DBName}, Value: v})
+			}
+		}
+		var n int64
+		if err := q.Count(&n).Error; err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			v, _ := fields[0].ValueOf(ctx, rv)
+			out = append(out, ConstraintViolation{Model: sch.Name, Field: fields[0].Name, Constraint: "unique", Value: v})
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,72 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Tenancy returns a scoped UnitOfWork for a single tenant. Every entity it creates, updates
+// or deletes gets its tenantIDField struct field set to tenantID before the write, and its
+// reads, updates and deletes are restricted to rows whose matching column equals tenantID.
+// Updating or deleting an entity whose row belongs to another tenant, or does not exist,
+// fails the commit with an error wrapping ErrNotFound instead of touching the row. Queuing
+// an entity without that field fails the commit. Scopes derived from the returned
+// UnitOfWork, such as Clone, BatchSize or WithActor, and GroupCommit through it, keep the
+// tenant.
+func (r *UnitOfWork) Tenancy(_ context.Context, tenantIDField string, tenantID any) *UnitOfWork {
+	root := r.mustRoot()
+	col := root.NamingStrategy.ColumnName("", tenantIDField)
+	s := r.scoped(root.Where(clause.Eq{Column: clause.Column{Name: col}, Value: tenantID}).Session(&gorm.Session{}))
+	s.opts.tenant = &tenant{field: tenantIDField, id: tenantID}
+	return s
+}
+
+// tenant is the tenant a Tenancy UnitOfWork, and every scope derived from it, writes as.
+type tenant struct {
+	id    any
+	field string
+}
+
+// stamp sets the tenant on every entity c creates, updates or deletes.
+func (t *tenant) stamp(c changes) error {
+	for _, list := range [][]any{c.creates, c.updates, c.deletes} {
+		for _, e := range list {
+			if err := setTenant(reflect.ValueOf(e), t.field, t.id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setTenant sets the named field on the struct, pointer or slice of them held by v.
+func setTenant(v reflect.Value, field string, tenantID any) error {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return setTenant(v.Elem(), field, tenantID)
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			if err := setTenant(v.Index(i), field, tenantID); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		f := v.FieldByName(field)
+		if !f.IsValid() || !f.CanSet() {
+			return fmt.Errorf("tracker: tenancy: %s has no settable field %s", v.Type(), field)
+		}
+		id := reflect.ValueOf(tenantID)
+		if !id.IsValid() || !id.Type().ConvertibleTo(f.Type()) {
+			return fmt.Errorf("tracker: tenancy: cannot assign %T to %s.%s", tenantID, v.Type(), field)
+		}
+		f.Set(id.Convert(f.Type()))
+		return nil
+	default:
+		return fmt.Errorf("tracker: tenancy: cannot set %s on %s", field, v.Type())
+	}
+}
@@ -0,0 +1,119 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type tenantItem struct {
+	TenantID string
+	Name     string
+	ID       uint
+}
+
+func TestTenancyCrossTenantWrites(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		queue func(b *UnitOfWork, id uint)
+		name  string
+	}{
+		{name: "update", queue: func(b *UnitOfWork, id uint) { b.Update(&tenantItem{ID: id, Name: "hijacked"}) }},
+		{name: "delete", queue: func(b *UnitOfWork, id uint) { b.RegisterDelete(&tenantItem{ID: id}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&tenantItem{}})
+			a := uow.Tenancy(ctx, "TenantID", "A")
+			item := &tenantItem{Name: "original"}
+			a.Add(item)
+			mustCommit(t, a)
+
+			b := uow.Tenancy(ctx, "TenantID", "B")
+			tt.queue(b, item.ID)
+			if err := b.Commit(ctx); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Commit = %v, want ErrNotFound", err)
+			}
+
+			var got tenantItem
+			if err := uow.First(ctx, &got, item.ID); err != nil {
+				t.Fatalf("First: %v", err)
+			}
+			if got.Name != "original" || got.TenantID != "A" {
+				t.Fatalf("row = %+v, want tenant A's original row", got)
+			}
+		})
+	}
+}
+
+func TestTenancyOwnWrites(t *testing.T) {
+	ctx := context.Background()
+	uow := newTestUoW(t, []any{&tenantItem{}})
+	a := uow.Tenancy(ctx, "TenantID", "A")
+	item := &tenantItem{Name: "original"}
+	a.Add(item)
+	mustCommit(t, a)
+
+	a.Update(&tenantItem{ID: item.ID, Name: "renamed"})
+	mustCommit(t, a)
+	var got tenantItem
+	if err := a.First(ctx, &got, item.ID); err != nil || got.Name != "renamed" || got.TenantID != "A" {
+		t.Fatalf("after update: %+v, %v", got, err)
+	}
+
+	a.RegisterDelete(&tenantItem{ID: item.ID})
+	mustCommit(t, a)
+	if err := uow.First(ctx, &got, item.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("after delete: First = %v, want ErrNotFound", err)
+	}
+}
+
+func TestTenancySurvivesScopes(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		write func(a *UnitOfWork, item *tenantItem) error
+		name  string
+	}{
+		{name: "Clone", write: func(a *UnitOfWork, item *tenantItem) error {
+			c := a.Clone()
+			c.Add(item)
+			return c.Commit(ctx)
+		}},
+		{name: "BatchSize", write: func(a *UnitOfWork, item *tenantItem) error {
+			b := a.BatchSize(10)
+			b.Add(item)
+			return b.Commit(ctx)
+		}},
+		{name: "WithActor", write: func(a *UnitOfWork, item *tenantItem) error {
+			w := a.WithActor("bob")
+			w.Add(item)
+			return w.Commit(ctx)
+		}},
+		{name: "WithStrictMode", write: func(a *UnitOfWork, item *tenantItem) error {
+			w := a.WithStrictMode()
+			w.Add(item)
+			return w.Commit(ctx)
+		}},
+		{name: "GroupCommit", write: func(a *UnitOfWork, item *tenantItem) error {
+			errs := GroupCommit(ctx, a, []any{item}, func(any) string { return "g" })
+			return errs["g"]
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&tenantItem{}})
+			a := uow.Tenancy(ctx, "TenantID", "A")
+			item := &tenantItem{Name: "scoped"}
+			if err := tt.write(a, item); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			var got tenantItem
+			if err := a.First(ctx, &got, item.ID); err != nil {
+				t.Fatalf("tenant A cannot read its own row: %v", err)
+			}
+			if got.TenantID != "A" {
+				t.Fatalf("TenantID = %q, want A", got.TenantID)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package tracker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CommittableUoW is a UnitOfWork bound to a transaction the caller ends explicitly, in the
+// style of database/sql: queue work and read through it, then call Commit or Rollback
+// exactly once. Reads through the handle run inside the transaction. Only the queueing and
+// reading methods of UnitOfWork are available, so the work cannot be committed other than
+// by Commit.
+type CommittableUoW struct {
+	uow  *UnitOfWork
+	tx   *gorm.DB
+	ctx  context.Context
+	mu   sync.Mutex
+	done bool
+}
+
+// Transaction begins a transaction and returns a handle to it. Nothing queued on the handle
+// is visible to other connections until its Commit.
+func (r *UnitOfWork) Transaction(ctx context.Context) (*CommittableUoW, error) {
+	tx := r.conn(ctx).Begin(r.opts.txOptions()...)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return &CommittableUoW{uow: r.scoped(tx), tx: tx, ctx: ctx}, nil
+}
+
+// Commit applies the queued work inside the transaction and commits it. If the work fails,
+// the transaction is rolled back and the error returned. After-commit callbacks and domain
+// events fire only once the transaction is committed. Calling Commit or Rollback again
+// returns sql.ErrTxDone.
+func (r *CommittableUoW) Commit() error {
+	if err := r.end(); err != nil {
+		return err
+	}
+	uow := r.uow
+	leave, err := uow.enterCommit()
+	if err != nil {
+		return errors.Join(err, r.tx.Rollback().Error)
+	}
+	defer leave()
+	c := uow.snapshot()
+	err = uow.writePlan(c)
+	if err == nil && uow.opts.strict {
+		err = uow.checkConstraints(r.ctx, c)
+	}
+	if err == nil {
+		err = uow.apply(uow.conn(r.ctx), c)
+	}
+	if err != nil {
+		return uow.finish(r.ctx, c, errors.Join(err, r.tx.Rollback().Error))
+	}
+	return uow.finish(r.ctx, c, r.tx.Commit().Error)
+}
+
+// Rollback discards the transaction and everything queued on the handle, running the
+// after-rollback callbacks. Calling Commit or Rollback again returns sql.ErrTxDone.
+func (r *CommittableUoW) Rollback() error {
+	if err := r.end(); err != nil {
+		return err
+	}
+	c := r.uow.snapshot()
+	r.uow.Clear()
+	err := r.tx.Rollback().Error
+	for _, cb := range c.afterRollback {
+		func() { defer func() { _ = recover() }(); cb() }()
+	}
+	return err
+}
+
+// end marks the transaction as finalized.
+func (r *CommittableUoW) end() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done {
+		return sql.ErrTxDone
+	}
+	r.done = true
+	return nil
+}
+
+// Add is UnitOfWork.Add inside the transaction.
+func (r *CommittableUoW) Add(entity any) { r.uow.Add(entity) }
+
+// ConditionalAdd is UnitOfWork.ConditionalAdd inside the transaction.
+func (r *CommittableUoW) ConditionalAdd(entity any, condition func() bool) {
+	r.uow.ConditionalAdd(entity, condition)
+}
+
+// Update is UnitOfWork.Update inside the transaction.
+func (r *CommittableUoW) Update(entity any) { r.uow.Update(entity) }
+
+// RegisterDelete is UnitOfWork.RegisterDelete inside the transaction.
+func (r *CommittableUoW) RegisterDelete(entity any) { r.uow.RegisterDelete(entity) }
+
+// Undelete is UnitOfWork.Undelete inside the transaction.
+func (r *CommittableUoW) Undelete(entity any) { r.uow.Undelete(entity) }
+
+// Do is UnitOfWork.Do inside the transaction.
+func (r *CommittableUoW) Do(op Operation) { r.uow.Do(op) }
+
+// DoWithContext is UnitOfWork.DoWithContext inside the transaction.
+func (r *CommittableUoW) DoWithContext(op ContextOperation) { r.uow.DoWithContext(op) }
+
+// DoWithPriority is UnitOfWork.DoWithPriority inside the transaction.
+func (r *CommittableUoW) DoWithPriority(op Operation, p Priority) { r.uow.DoWithPriority(op, p) }
+
+// TimedDo is UnitOfWork.TimedDo inside the transaction.
+func (r *CommittableUoW) TimedDo(d time.Duration, op Operation) { r.uow.TimedDo(d, op) }
+
+// Savepoint is UnitOfWork.Savepoint inside the transaction.
+func (r *CommittableUoW) Savepoint(name string, onError func(error), ops ...Operation) {
+	r.uow.Savepoint(name, onError, ops...)
+}
+
+// CreateSavepoint is UnitOfWork.CreateSavepoint inside the transaction.
+func (r *CommittableUoW) CreateSavepoint(name string) { r.uow.CreateSavepoint(name) }
+
+// RollbackSavepoint is UnitOfWork.RollbackSavepoint inside the transaction.
+func (r *CommittableUoW) RollbackSavepoint(name string) { r.uow.RollbackSavepoint(name) }
+
+// ReleaseSavepoint is UnitOfWork.ReleaseSavepoint inside the transaction.
+func (r *CommittableUoW) ReleaseSavepoint(name string) { r.uow.ReleaseSavepoint(name) }
+
+// RecordEvent is UnitOfWork.RecordEvent; the event is published after Commit.
+func (r *CommittableUoW) RecordEvent(event DomainEvent) { r.uow.RecordEvent(event) }
+
+// OnCommitPublish is UnitOfWork.OnCommitPublish; the message is published after Commit.
+func (r *CommittableUoW) OnCommitPublish(topic string, payload any) {
+	r.uow.OnCommitPublish(topic, payload)
+}
+
+// AfterCommit registers cb to run after Commit succeeds.
+func (r *CommittableUoW) AfterCommit(cb func()) { r.uow.AfterCommit(cb) }
+
+// AfterRollback registers cb to run after Rollback, or after Commit fails.
+func (r *CommittableUoW) AfterRollback(cb func()) { r.uow.AfterRollback(cb) }
+
+// Clear discards the queued work; the transaction stays open.
+func (r *CommittableUoW) Clear() { r.uow.Clear() }
+
+// HasPending reports whether work is queued.
+func (r *CommittableUoW) HasPending() bool { return r.uow.HasPending() }
+
+// PendingCount returns the number of queued items.
+func (r *CommittableUoW) PendingCount() int { return r.uow.PendingCount() }
+
+// PendingOperations returns the queued items in the order Commit would execute them.
+func (r *CommittableUoW) PendingOperations() []PendingOp { return r.uow.PendingOperations() }
+
+// First is UnitOfWork.First inside the transaction.
+func (r *CommittableUoW) First(ctx context.Context, out any, conds ...any) error {
+	return r.uow.First(ctx, out, conds...)
+}
+
+// PreloadFirst is UnitOfWork.PreloadFirst inside the transaction.
+func (r *CommittableUoW) PreloadFirst(ctx context.Context, out any, id any, preloads ...string) error {
+	return r.uow.PreloadFirst(ctx, out, id, preloads...)
+}
+
+// FindAll is UnitOfWork.FindAll inside the transaction.
+func (r *CommittableUoW) FindAll(ctx context.Context, out any, opts ...QueryOption) error {
+	return r.uow.FindAll(ctx, out, opts...)
+}
+
+// Count is UnitOfWork.Count inside the transaction.
+func (r *CommittableUoW) Count(ctx context.Context, model any, opts ...QueryOption) (int64, error) {
+	return r.uow.Count(ctx, model, opts...)
+}
+
+// Exists is UnitOfWork.Exists inside the transaction.
+func (r *CommittableUoW) Exists(ctx context.Context, model any, opts ...QueryOption) (bool, error) {
+	return r.uow.Exists(ctx, model, opts...)
+}
@@ -0,0 +1,93 @@
+package tracker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type txItem struct {
+	Name string
+	ID   uint
+}
+
+func TestTransaction(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		end          func(h *CommittableUoW) error
+		name         string
+		wantRows     int64
+		wantRollback bool
+	}{
+		{name: "Commit", end: (*CommittableUoW).Commit, wantRows: 2},
+		{name: "Rollback", end: (*CommittableUoW).Rollback, wantRollback: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&txItem{}})
+			h, err := uow.Transaction(ctx)
+			if err != nil {
+				t.Fatalf("Transaction: %v", err)
+			}
+			h.Add(&txItem{Name: "a"})
+			h.Do(func(tx Tx) error { return tx.Create(&txItem{Name: "b"}) })
+			rolledBack := false
+			h.AfterRollback(func() { rolledBack = true })
+			if h.PendingCount() != 2 {
+				t.Fatalf("PendingCount = %d, want 2", h.PendingCount())
+			}
+			if err := tt.end(h); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if rolledBack != tt.wantRollback {
+				t.Fatalf("rolled back = %t, want %t", rolledBack, tt.wantRollback)
+			}
+			if err := h.Commit(); !errors.Is(err, sql.ErrTxDone) {
+				t.Fatalf("second Commit = %v, want sql.ErrTxDone", err)
+			}
+			if n, err := uow.Count(ctx, &txItem{}); err != nil || n != tt.wantRows {
+				t.Fatalf("Count = %d, %v, want %d", n, err, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestTransactionReadsInside(t *testing.T) {
+	ctx := context.Background()
+	uow := newTestUoW(t, []any{&txItem{}})
+	h, err := uow.Transaction(ctx)
+	if err != nil {
+		t.Fatalf("Transaction: %v", err)
+	}
+	h.Do(func(tx Tx) error { return tx.Create(&txItem{Name: "a"}) })
+	if err := h.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	h, err = uow.Transaction(ctx)
+	if err != nil {
+		t.Fatalf("Transaction: %v", err)
+	}
+	defer func() { _ = h.Rollback() }()
+	var got txItem
+	if err := h.First(ctx, &got); err != nil || got.Name != "a" {
+		t.Fatalf("First = %+v, %v, want a", got, err)
+	}
+	if ok, err := h.Exists(ctx, &txItem{}, Where("name", "=", "a")); err != nil || !ok {
+		t.Fatalf("Exists = %t, %v, want true", ok, err)
+	}
+}
+
+func TestTransactionHidesCommitPaths(t *testing.T) {
+	typ := reflect.TypeFor[*CommittableUoW]()
+	for _, name := range []string{"SaveChanges", "BatchCommit", "WithTx", "Clone", "RunWithRetry"} {
+		if _, ok := typ.MethodByName(name); ok {
+			t.Errorf("CommittableUoW exposes %s", name)
+		}
+	}
+	if m, _ := typ.MethodByName("Commit"); m.Type.NumIn() != 1 {
+		t.Errorf("Commit takes %d arguments, want none besides the receiver", m.Type.NumIn()-1)
+	}
+}
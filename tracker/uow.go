@@ -3,9 +3,15 @@ package tracker
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -15,6 +21,7 @@ type Tx interface {
 	Create(value any) error
 	Save(value any) error
 	Delete(value any, conds ...any) error
+	Exec(sql string, values ...any) error
 }
 
 type gormTx struct{ db *gorm.DB }
@@ -22,11 +29,17 @@ type gormTx struct{ db *gorm.DB }
 func (r gormTx) Create(value any) error               { return r.db.Create(value).Error }
 func (r gormTx) Save(value any) error                 { return r.db.Save(value).Error }
 func (r gormTx) Delete(value any, conds ...any) error { return r.db.Delete(value, conds...).Error }
+func (r gormTx) Exec(sql string, values ...any) error { return r.db.Exec(sql, values...).Error }
+func (r gormTx) gormDB() *gorm.DB                     { return r.db }
 
 // Operation represents a deferred operation to be executed inside the transaction.
 // It receives an abstract Tx to avoid leaking GORM to the outside world.
 type Operation func(tx Tx) error
 
+// ContextOperation is an Operation that also receives the context passed to SaveChanges,
+// so it can observe cancellation and deadlines while the transaction is open.
+type ContextOperation func(ctx context.Context, tx Tx) error
+
 // UnitOfWork implements a simple Unit of Work pattern on top of GORM.
 // It collects changes and applies them in a single transaction on SaveChanges/SaveChanges.
 // It also provides a basic object tracker, similar in spirit to EF's ChangeTracker,
@@ -34,69 +47,452 @@ type Operation func(tx Tx) error
 // arbitrary functions via Do.
 type UnitOfWork struct {
 	root *gorm.DB
+	opts options
+	// owned is the connection opened by NewFromDSN, which Close releases.
+	owned *sql.DB
+	// shards and shardFn route commits across databases; see NewSharded.
+	shards  []*UnitOfWork
+	shardFn func(entity any) int
 
-	ops      []Operation
+	ops      []queuedOp
 	toCreate []any
 	toUpdate []any
 	toDelete []any
+	events   []DomainEvent
+	// messages are published by WithPublisher after the commit; see OnCommitPublish.
+	messages []message
+
+	// savepoints holds the names opened by CreateSavepoint and not yet rolled back to or
+	// released, in queue order.
+	savepoints []string
+	// savepointOps counts the queued statements of CreateSavepoint, RollbackSavepoint and
+	// ReleaseSavepoint, which BatchCommit cannot split across chunks.
+	savepointOps int
+	// queueErr is the first error a queueing method could not return; Commit reports it.
+	queueErr error
 
 	// afterCommit contains callbacks to run after a successful commit (outside tx)
 	afterCommit []func()
 	// afterRollback contains callbacks to run after a rollback (outside tx)
 	afterRollback []func()
+	// interceptors transform pending items at commit time; see Intercept
+	interceptors []func(PendingOp) (PendingOp, error)
+
+	// commitDepth counts the Commit calls in progress, to stop runaway reentrancy.
+	commitDepth atomic.Int32
 
 	mu sync.Mutex
 }
 
-// gormRoots caches a single *gorm.DB per *sql.DB so we don't call gorm.Open on every tracker.New.
-// This keeps the public API simple while avoiding repeated initialization cost.
-// Note: entries are not pruned automatically; ensure you reuse *sql.DB for app lifetime.
+// gormRoots caches a single *gorm.DB per *sql.DB and GORM configuration so we don't call gorm.Open
+// on every tracker.New. This keeps the public API simple while avoiding repeated initialization cost.
+// Note: entries are not pruned automatically; ensure you reuse *sql.DB for app lifetime, or
+// call CompactGormRoots after closing pools.
 var gormRoots sync.Map
 
+// errDBClosed is the message database/sql reports for operations on a closed *sql.DB.
+const errDBClosed = "sql: database is closed"
+
+// CompactGormRoots drops the cached GORM roots of *sql.DB pools that have been closed,
+// found by pinging each cached pool, and returns how many entries it removed. Pings that
+// fail for other reasons (e.g. an unreachable server) keep their entries. It is safe to call
+// concurrently with New and other UoW use.
+func CompactGormRoots(ctx context.Context) (evicted int) {
+	closed := map[*sql.DB]bool{}
+	gormRoots.Range(func(k, _ any) bool {
+		db := k.(rootKey).sqlDB
+		if _, seen := closed[db]; !seen {
+			err := db.PingContext(ctx)
+			closed[db] = err != nil && err.Error() == errDBClosed
+		}
+		if closed[db] {
+			if _, loaded := gormRoots.LoadAndDelete(k); loaded {
+				evicted++
+			}
+		}
+		return true
+	})
+	return evicted
+}
+
+// GormRootCount returns the number of cached GORM roots.
+func GormRootCount() int {
+	n := 0
+	gormRoots.Range(func(_, _ any) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// rootKey identifies a cached GORM root. The same *sql.DB opened with options that change
+// the GORM configuration (e.g. WithNamingStrategy) gets a root of its own.
+type rootKey struct {
+	sqlDB  *sql.DB
+	config string
+}
+
 // New creates a new UnitOfWork using the provided standard sql.DB as the root connection.
-// Internally, it uses GORM with the SQLite driver, but callers don't need to know that.
-func New(sqlDB *sql.DB) *UnitOfWork {
-	if v, ok := gormRoots.Load(sqlDB); ok {
-		return &UnitOfWork{root: v.(*gorm.DB)}
+// Internally, it uses GORM with the dialect registered for sqlDB's driver (see
+// NewWithDialect), or SQLite for unknown drivers, but callers don't need to know that.
+//
+// Deprecated: New ignores initialization errors; the returned UnitOfWork then panics on
+// first use. Use NewWithOptions, which reports them.
+func New(sqlDB *sql.DB, opts ...Option) *UnitOfWork {
+	// Ignoring the open error preserves previous behavior, but root may be nil.
+	uow, _ := newUnitOfWork(sqlDB, newOptions(opts))
+	return uow
+}
+
+// NewWithOptions creates a new UnitOfWork on sqlDB, returning an error if the underlying
+// GORM root cannot be initialized (e.g. the database is closed or unreachable, or its
+// driver's dialect is not registered). The dialect is chosen as New does.
+func NewWithOptions(sqlDB *sql.DB, opts ...Option) (*UnitOfWork, error) {
+	uow, err := newUnitOfWork(sqlDB, newOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	return uow, nil
+}
+
+// MustNew is like NewWithOptions but panics if the UnitOfWork cannot be initialized.
+// It is meant for program startup, where there is no sensible way to continue.
+func MustNew(sqlDB *sql.DB, opts ...Option) *UnitOfWork {
+	uow, err := NewWithOptions(sqlDB, opts...)
+	if err != nil {
+		panic(fmt.Sprintf("tracker.MustNew: %v", err))
 	}
-	gdb, err := gorm.Open(sqlite.Dialector{Conn: sqlDB}, &gorm.Config{})
-	if err == nil && gdb != nil {
-		actual, _ := gormRoots.LoadOrStore(sqlDB, gdb)
-		return &UnitOfWork{root: actual.(*gorm.DB)}
+	return uow
+}
+
+// NewWithGormConfig creates a new UnitOfWork on sqlDB opened through dialector with config
+// passed to gorm.Open as-is, for settings the options do not cover (logger, prepared
+// statements, SkipDefaultTransaction, ...). A nil dialector is chosen from sqlDB's driver,
+// as NewWithOptions does. Roots are cached per sqlDB and config settings, so distinct
+// configurations of the same *sql.DB do not share one; configs that differ only in their
+// Logger or NowFunc instance do, and each UnitOfWork uses its own. config is copied and may
+// be reused by the caller.
+func NewWithGormConfig(sqlDB *sql.DB, config *gorm.Config, dialector gorm.Dialector, opts ...Option) (*UnitOfWork, error) {
+	o := newOptions(opts)
+	o.config = config
+	o.dialector = dialector
+	uow, err := newUnitOfWork(sqlDB, o)
+	if err != nil {
+		return nil, err
 	}
-	// Fallback preserves previous behavior of ignoring open errors, but root may be nil.
-	return &UnitOfWork{root: gdb}
+	return uow, nil
+}
+
+// NewFromDSN opens a SQLite database from dsn and returns a UnitOfWork that owns the
+// connection; release it with Close.
+func NewFromDSN(dsn string, opts ...Option) (*UnitOfWork, error) {
+	sqlDB, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("tracker: open %q: %w", dsn, err)
+	}
+	uow, err := NewWithOptions(sqlDB, opts...)
+	if err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+	uow.owned = sqlDB
+	uow.opts.dsn = dsn
+	return uow, nil
+}
+
+// Close closes the connection owned by a UnitOfWork created with NewFromDSN and evicts it
+// from the root cache. It returns ErrNotOwned for UoWs built on a caller-provided *sql.DB,
+// whose lifetime stays with the caller.
+func (r *UnitOfWork) Close() error {
+	if r.owned == nil {
+		return ErrNotOwned
+	}
+	gormRoots.Range(func(k, _ any) bool {
+		if k.(rootKey).sqlDB == r.owned {
+			gormRoots.Delete(k)
+		}
+		return true
+	})
+	return r.owned.Close()
+}
+
+// newUnitOfWork builds a UnitOfWork for sqlDB. On error the UoW is still returned,
+// with a nil root, for New's sake.
+func newUnitOfWork(sqlDB *sql.DB, o options) (*UnitOfWork, error) {
+	gdb, err := openRoot(sqlDB, o)
+	if err != nil {
+		return &UnitOfWork{opts: o}, fmt.Errorf("tracker: initialize unit of work: %w", err)
+	}
+	// rootSQLHooks sets o.n1 and o.queries, so it must run before o is stored.
+	hooks := o.rootSQLHooks(gdb.Dialector.Name())
+	uow := &UnitOfWork{root: withSQLHooks(o.configSession(gdb), hooks), opts: o}
+	if len(o.migrate) > 0 {
+		if err = uow.AutoMigrate(o.migrate...); err != nil {
+			return uow, fmt.Errorf("tracker: auto-migrate: %w", err)
+		}
+	}
+	return uow, nil
+}
+
+// openRoot returns the cached GORM root for sqlDB and o, opening it on first use.
+func openRoot(sqlDB *sql.DB, o options) (*gorm.DB, error) {
+	key := rootKey{sqlDB: sqlDB, config: o.configKey()}
+	if v, ok := gormRoots.Load(key); ok {
+		return v.(*gorm.DB), nil
+	}
+	dialector := o.dialector
+	if dialector == nil {
+		var err error
+		if dialector, err = defaultDialector(sqlDB); err != nil {
+			return nil, err
+		}
+	}
+	gdb, err := gorm.Open(dialector, o.gormConfig())
+	if err != nil {
+		return nil, err
+	}
+	if err = errors.Join(installTableHooks(gdb), installEncryptionHooks(gdb)); err != nil {
+		return nil, err
+	}
+	actual, _ := gormRoots.LoadOrStore(key, gdb)
+	return actual.(*gorm.DB), nil
+}
+
+// conn returns the root bound to ctx, switched to the connection chosen by the
+// WithConnectionResolver option when set. UoWs rooted at a transaction keep it.
+func (r *UnitOfWork) conn(ctx context.Context) *gorm.DB {
+	db := r.mustRoot().WithContext(ctx)
+	if r.opts.resolver == nil {
+		return db
+	}
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); inTx {
+		return db
+	}
+	if sqlDB := r.opts.resolver(ctx); sqlDB != nil {
+		db.Statement.ConnPool = sqlDB
+	}
+	return db
+}
+
+// mustRoot returns the GORM root, panicking with an explanation instead of a nil dereference
+// when the UnitOfWork came from a New call whose initialization failed.
+func (r *UnitOfWork) mustRoot() *gorm.DB {
+	if r.root == nil {
+		panic("tracker: UnitOfWork has no database connection; it was created by New with a database " +
+			"that could not be initialized. Use NewWithOptions to get the error")
+	}
+	return r.root
+}
+
+// Clone returns an empty UnitOfWork with r's connection, scope and options, for work that
+// must commit independently of r's queue.
+func (r *UnitOfWork) Clone() *UnitOfWork {
+	c := r.scoped(r.root)
+	c.shards, c.shardFn = r.shards, r.shardFn
+	return c
+}
+
+// scoped returns an empty UnitOfWork sharing r's options but rooted at db.
+func (r *UnitOfWork) scoped(db *gorm.DB) *UnitOfWork {
+	return &UnitOfWork{root: db, opts: r.opts}
 }
 
 // AutoMigrate runs auto-migrations for the given models without exposing GORM.
-func (r *UnitOfWork) AutoMigrate(models ...any) error { return r.root.AutoMigrate(models...) }
+func (r *UnitOfWork) AutoMigrate(models ...any) error { return r.mustRoot().AutoMigrate(models...) }
 
 // Do queue a custom operation to be executed inside the transaction at commit time.
-func (r *UnitOfWork) Do(op Operation) {
+func (r *UnitOfWork) Do(op Operation) { r.DoWithPriority(op, PriorityNormal) }
+
+// DoWithContext queues a custom operation like Do, passing it the commit's context.
+func (r *UnitOfWork) DoWithContext(op ContextOperation) {
+	r.Do(func(tx Tx) error { return op(txContext(tx), tx) })
+}
+
+// TimedDo queues a custom operation like Do that must finish within d. Its Tx runs under a
+// context that expires after d, so statements issued past the deadline fail; if op outlasts
+// d, the commit fails with an error wrapping context.DeadlineExceeded and is rolled back.
+// Go code inside op is not interrupted, so op should return promptly on errors.
+func (r *UnitOfWork) TimedDo(d time.Duration, op Operation) {
+	r.Do(func(tx Tx) error {
+		ctx, cancel := context.WithTimeout(txContext(tx), d)
+		defer cancel()
+		if g, ok := tx.(interface{ gormDB() *gorm.DB }); ok {
+			tx = gormTx{db: g.gormDB().WithContext(ctx)}
+		}
+		err := op(tx)
+		if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+			return fmt.Errorf("tracker: operation exceeded %s: %w", d, errors.Join(ctxErr, err))
+		}
+		return err
+	})
+}
+
+// txContext returns the context tx was started with, or context.Background for Tx
+// implementations not created by this package.
+func txContext(tx Tx) context.Context {
+	if g, ok := tx.(interface{ gormDB() *gorm.DB }); ok && g.gormDB().Statement.Context != nil {
+		return g.gormDB().Statement.Context
+	}
+	return context.Background()
+}
+
+// DoWithPriority queues a custom operation like Do, choosing where it runs within the commit:
+// PriorityFirst before the creates, PriorityNormal after the deletes (as Do does), and
+// PriorityLast after everything else. Operations of the same priority run in queue order.
+func (r *UnitOfWork) DoWithPriority(op Operation, p Priority) {
+	if r.rejectsWork() {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ops = append(r.ops, queuedOp{op: op, priority: min(max(p, PriorityFirst), PriorityLast)})
+}
+
+// RecordRawSQL queues a hand-written statement, run with tx.Exec at commit time like any
+// other Do operation, for DDL and updates GORM cannot express.
+func (r *UnitOfWork) RecordRawSQL(sql string, args ...any) {
+	r.Do(func(tx Tx) error { return tx.Exec(sql, args...) })
+}
+
+// CreateSavepoint queues SAVEPOINT name as a Do operation. Savepoint operations are ordered
+// against other custom operations only, and entity writes run before them (see apply), so
+// work meant to be undone by RollbackSavepoint must be queued with Do as well. Calling Add,
+// Update or RegisterDelete before the savepoint is rolled back to or released makes Commit
+// fail with ErrEntityInSavepoint.
+func (r *UnitOfWork) CreateSavepoint(name string) {
+	r.savepointOp("SAVEPOINT ", name)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.savepoints = append(r.savepoints, name)
+}
+
+// RollbackSavepoint queues ROLLBACK TO SAVEPOINT name as a Do operation, undoing what the
+// operations queued since CreateSavepoint(name) did; the commit then goes on.
+func (r *UnitOfWork) RollbackSavepoint(name string) {
+	r.savepointOp("ROLLBACK TO SAVEPOINT ", name)
+	r.closeSavepoint(name)
+}
+
+// ReleaseSavepoint queues RELEASE SAVEPOINT name as a Do operation.
+func (r *UnitOfWork) ReleaseSavepoint(name string) {
+	r.savepointOp("RELEASE SAVEPOINT ", name)
+	r.closeSavepoint(name)
+}
+
+// closeSavepoint ends the block opened by CreateSavepoint(name) and those nested in it.
+func (r *UnitOfWork) closeSavepoint(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if i := slices.Index(r.savepoints, name); i >= 0 {
+		r.savepoints = r.savepoints[:i]
+	}
+}
+
+// queueEntity appends entity to *list, or records ErrEntityInSavepoint while a
+// CreateSavepoint block is open. The caller holds r.mu.
+func (r *UnitOfWork) queueEntity(list *[]any, entity any) {
+	if n := len(r.savepoints); n > 0 {
+		if r.queueErr == nil {
+			r.queueErr = fmt.Errorf("%w %q", ErrEntityInSavepoint, r.savepoints[n-1])
+		}
+		return
+	}
+	*list = append(*list, entity)
+}
+
+// Savepoint queues ops as one Do operation wrapped in SAVEPOINT name. If one of them fails,
+// the block is rolled back to the savepoint, the remaining ops are skipped, onError (when
+// non-nil) is called with the op's error, and the commit goes on with the next queued
+// operation as if the block had not been queued; otherwise the savepoint is released.
+func (r *UnitOfWork) Savepoint(name string, onError func(error), ops ...Operation) {
+	r.Do(func(tx Tx) error {
+		sp := quoteSavepoint(tx, name)
+		if err := tx.Exec("SAVEPOINT " + sp); err != nil {
+			return err
+		}
+		for _, op := range ops {
+			if opErr := op(tx); opErr != nil {
+				if err := tx.Exec("ROLLBACK TO SAVEPOINT " + sp); err != nil {
+					return err
+				}
+				if onError != nil {
+					onError(opErr)
+				}
+				break
+			}
+		}
+		return tx.Exec("RELEASE SAVEPOINT " + sp)
+	})
+}
+
+// savepointOp queues stmt followed by the quoted savepoint name.
+func (r *UnitOfWork) savepointOp(stmt, name string) {
+	r.Do(func(tx Tx) error { return tx.Exec(stmt + quoteSavepoint(tx, name)) })
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.ops = append(r.ops, op)
+	r.savepointOps++
+}
+
+// quoteSavepoint quotes a savepoint name for tx's dialect.
+func quoteSavepoint(tx Tx, name string) string {
+	if g, ok := tx.(interface{ gormDB() *gorm.DB }); ok {
+		return g.gormDB().Statement.Quote(name)
+	}
+	return name
 }
 
 // Add tracks an entity to be created on commit.
 func (r *UnitOfWork) Add(entity any) {
+	if r.rejectsWork() {
+		return
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.toCreate = append(r.toCreate, entity)
+	r.queueEntity(&r.toCreate, entity)
 }
 
 // Update tracks an entity to be updated on commit.
 func (r *UnitOfWork) Update(entity any) {
+	if r.rejectsWork() {
+		return
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.toUpdate = append(r.toUpdate, entity)
+	r.queueEntity(&r.toUpdate, entity)
 }
 
-// RegisterDelete tracks an entity to be deleted on commit.
+// RegisterDelete tracks an entity to be deleted on commit. Models with a gorm.DeletedAt field
+// are soft-deleted: the row stays, with deleted_at set, and reads skip it (see Undelete).
 func (r *UnitOfWork) RegisterDelete(entity any) {
+	if r.rejectsWork() {
+		return
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.toDelete = append(r.toDelete, entity)
+	r.queueEntity(&r.toDelete, entity)
+}
+
+// Undelete queues restoring a soft-deleted entity on commit by clearing its gorm.DeletedAt
+// field, in the row and in entity. It runs as a Do operation, after the commit's deletes.
+// Models without a gorm.DeletedAt field make the commit fail.
+func (r *UnitOfWork) Undelete(entity any) {
+	r.Do(func(tx Tx) error {
+		g, ok := tx.(interface{ gormDB() *gorm.DB })
+		if !ok {
+			return errors.New("tracker: Undelete outside a tracker transaction")
+		}
+		db := g.gormDB()
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(entity); err != nil {
+			return err
+		}
+		for _, f := range stmt.Schema.Fields {
+			if f.FieldType == reflect.TypeFor[gorm.DeletedAt]() && f.DBName != "" {
+				return db.Unscoped().Model(entity).Update(f.DBName, nil).Error
+			}
+		}
+		return fmt.Errorf("tracker: Undelete: %s has no gorm.DeletedAt field", stmt.Schema.Name)
+	})
 }
 
 // AfterCommit registers a callback to be executed after a successful commit (outside transaction).
@@ -120,47 +516,379 @@ func (r *UnitOfWork) SaveChanges(ctx context.Context) error { return r.Commit(ct
 // Commit begins a transaction and applies all pending operations.
 // On error, the transaction is rolled back and the pending operations remain queued
 // so the caller can inspect or retry if desired. Use Clear() to discard them.
+// When retries are configured (WithMaxRetries, RetryIfConflict) a matching failure re-runs
+// the whole queue, including Do operations, in a new transaction.
 func (r *UnitOfWork) Commit(ctx context.Context) error {
+	leave, err := r.enterCommit()
+	if err != nil {
+		return err
+	}
+	defer leave()
+	if err := r.writePlan(r.snapshot()); err != nil {
+		return err
+	}
+	if r.shards != nil {
+		return r.commitSharded(ctx)
+	}
+	if r.opts.strict {
+		if err := r.checkConstraints(ctx, r.snapshot()); err != nil {
+			return err
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		c := r.snapshot()
+		txErr := r.transaction(ctx, func(tx *gorm.DB) error { return r.apply(tx, c) })
+		if txErr == nil || attempt >= r.opts.maxRetries || !r.opts.isRetryable(txErr) {
+			return r.finish(ctx, c, txErr)
+		}
+	}
+}
+
+// BulkSaveChanges commits each of uows in turn, each in its own transaction, and returns
+// their errors aligned with uows (nil for those that committed). A failure does not stop
+// the UoWs after it from being committed.
+func BulkSaveChanges(ctx context.Context, uows []*UnitOfWork) []error {
+	errs := make([]error, len(uows))
+	for i, uow := range uows {
+		errs[i] = uow.SaveChanges(ctx)
+	}
+	return errs
+}
+
+// GroupCommit splits entities into groups by the key groupFn returns, e.g. a tenant ID, and
+// creates each group in its own transaction through a Clone of uow, so a failing group
+// does not affect the others. Groups commit one after another in order of first
+// appearance. The result maps the keys of the failed groups to their errors; it is empty
+// when every group committed. Work already queued on uow is not included.
+func GroupCommit(ctx context.Context, uow *UnitOfWork, entities []any, groupFn func(any) string) map[string]error {
+	var keys []string
+	groups := map[string]*UnitOfWork{}
+	for _, e := range entities {
+		key := groupFn(e)
+		g, ok := groups[key]
+		if !ok {
+			g = uow.Clone()
+			groups[key] = g
+			keys = append(keys, key)
+		}
+		g.Add(e)
+	}
+	errs := map[string]error{}
+	for _, key := range keys {
+		if err := groups[key].Commit(ctx); err != nil {
+			errs[key] = err
+		}
+	}
+	return errs
+}
+
+// enterCommit registers a commit in progress, failing with ErrMaxDepthExceeded past the
+// configured depth, with ErrReadOnly on a read-only UnitOfWork, with the error a queueing
+// method recorded (see queueEntity), or with the context error once the context linked by
+// WithCancelOnError is canceled. The returned func must be called when the commit ends.
+func (r *UnitOfWork) enterCommit() (func(), error) {
+	if r.opts.readOnly {
+		return nil, ErrReadOnly
+	}
+	if err := r.canceled(); err != nil {
+		r.Clear()
+		return nil, err
+	}
+	if err := r.queueError(); err != nil {
+		return nil, err
+	}
+	leave := func() { r.commitDepth.Add(-1) }
+	if r.commitDepth.Add(1) > max(r.opts.maxCommitDepth, 1) {
+		leave()
+		return nil, ErrMaxDepthExceeded
+	}
+	return leave, nil
+}
+
+// transaction runs fn in the transaction a commit applies its work in: a new one, or a
+// nested savepoint when the UoW is bound to an open transaction. WithSavepoint UoWs wrap fn
+// in their named savepoint instead, rolling back to it if fn fails.
+func (r *UnitOfWork) transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	db := r.conn(ctx)
+	name := r.opts.savepoint
+	if name == "" {
+		return db.Transaction(fn, r.opts.txOptions()...)
+	}
+	inSavepoint := func(tx *gorm.DB) error {
+		sp := tx.Statement.Quote(name)
+		if err := tx.Exec("SAVEPOINT " + sp).Error; err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			if rbErr := tx.Exec("ROLLBACK TO SAVEPOINT " + sp).Error; rbErr != nil {
+				return errors.Join(err, rbErr)
+			}
+			return err
+		}
+		return tx.Exec("RELEASE SAVEPOINT " + sp).Error
+	}
+	if _, inTx := db.Statement.ConnPool.(gorm.TxCommitter); inTx {
+		return inSavepoint(db)
+	}
+	return db.Transaction(inSavepoint, r.opts.txOptions()...)
+}
+
+// BatchCommit applies the pending work in chunks of at most batchSize items, each chunk in
+// its own transaction, to keep huge change sets from exceeding lock limits. Items keep
+// Commit's execution order. It stops at the first failing chunk: earlier chunks stay
+// committed and are removed from the queue, the rest remain queued.
+// After-commit callbacks and domain events fire only once every chunk succeeded. UoWs from
+// NewSharded ignore batchSize and commit as Commit does. A queue holding CreateSavepoint,
+// RollbackSavepoint or ReleaseSavepoint statements fails with ErrSavepointInBatch, since a
+// chunk boundary could separate them; commit it with Commit instead.
+func (r *UnitOfWork) BatchCommit(ctx context.Context, batchSize int) error {
+	if batchSize <= 0 || r.shards != nil {
+		return r.Commit(ctx)
+	}
+	leave, err := r.enterCommit()
+	if err != nil {
+		return err
+	}
+	defer leave()
 	r.mu.Lock()
-	deferredOps := make([]Operation, len(r.ops))
-	copy(deferredOps, r.ops)
-	creates := append([]any(nil), r.toCreate...)
-	updates := append([]any(nil), r.toUpdate...)
-	deletes := append([]any(nil), r.toDelete...)
-	afterCommit := append([]func(){}, r.afterCommit...)
-	afterRollback := append([]func(){}, r.afterRollback...)
+	splitsSavepoints := r.savepointOps > 0
 	r.mu.Unlock()
+	if splitsSavepoints {
+		return ErrSavepointInBatch
+	}
+	c := r.snapshot()
+	if err := r.writePlan(c); err != nil {
+		return err
+	}
+	if r.opts.strict {
+		if err := r.checkConstraints(ctx, c); err != nil {
+			return err
+		}
+	}
+	items := c.pendingOps()
+	for start := 0; start < len(items); start += batchSize {
+		end := min(start+batchSize, len(items))
+		chunk := c.withItems(items[start:end])
+		err = r.transaction(ctx, func(tx *gorm.DB) error { return r.apply(tx, chunk) })
+		if err != nil {
+			r.dequeue(items[:start])
+			return r.finish(ctx, c, err)
+		}
+	}
+	return r.finish(ctx, c, nil)
+}
 
-	txErr := r.root.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// 1. Apply creates
-		for _, e := range creates {
-			if err := tx.Create(e).Error; err != nil {
-				return err
-			}
+// DeferredConstraintCheck returns a scoped UnitOfWork whose commits postpone foreign-key
+// checks to the end of the transaction, so children can be written before their parents:
+// SET CONSTRAINTS ALL DEFERRED on PostgreSQL (for constraints declared DEFERRABLE) and
+// PRAGMA defer_foreign_keys on SQLite. Other dialects are left unchanged.
+func (r *UnitOfWork) DeferredConstraintCheck() *UnitOfWork {
+	s := r.scoped(r.mustRoot())
+	s.opts.addOnBegin(func(tx *gorm.DB) error {
+		switch tx.Dialector.Name() {
+		case "postgres":
+			return tx.Exec("SET CONSTRAINTS ALL DEFERRED").Error
+		case "sqlite":
+			return tx.Exec("PRAGMA defer_foreign_keys = ON").Error
 		}
-		// 2. Apply updates
-		for _, e := range updates {
-			if err := tx.Save(e).Error; err != nil { // Save handles both insert/update by PK, but we used Add above for clarity
-				return err
+		return nil
+	})
+	return s
+}
+
+// RetryIfConflict returns a scoped UnitOfWork whose commits retry up to n more times, but
+// only on unique-constraint violations (SQLite SQLITE_CONSTRAINT_UNIQUE, PostgreSQL 23505).
+// Do operations run again on every attempt, so they can regenerate the colliding value
+// before the retry.
+func (r *UnitOfWork) RetryIfConflict(n int) *UnitOfWork {
+	s := r.scoped(r.mustRoot())
+	s.opts.maxRetries = n
+	s.opts.isRetryable = isUniqueViolation
+	return s
+}
+
+// RunWithRetry calls fn with a fresh, empty UnitOfWork scoped from r and commits whatever
+// fn queued on it. If fn or the commit fails with a retryable error (the predicate set by
+// WithMaxRetries or RetryIfConflict, transient failures by default), the queued work is
+// discarded and fn is called again on a new UoW, up to maxAttempts calls in total.
+// The last error is returned.
+func (r *UnitOfWork) RunWithRetry(ctx context.Context, maxAttempts int, fn func(uow *UnitOfWork) error) error {
+	retryable := r.opts.isRetryable
+	if retryable == nil {
+		retryable = isSerializationFailure
+	}
+	for attempt := 1; ; attempt++ {
+		s := r.scoped(r.mustRoot())
+		s.opts.maxRetries = 0 // retries happen here, around fn
+		err := fn(s)
+		if err == nil {
+			err = s.Commit(ctx)
+		}
+		if err == nil || attempt >= maxAttempts || !retryable(err) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// changes is a point-in-time copy of the work queued on a UnitOfWork.
+type changes struct {
+	ops           []queuedOp
+	creates       []any
+	updates       []any
+	deletes       []any
+	events        []DomainEvent
+	messages      []message
+	afterCommit   []func()
+	afterRollback []func()
+	interceptors  []func(PendingOp) (PendingOp, error)
+}
+
+// snapshot copies the pending work so the lock is not held while talking to the database.
+func (r *UnitOfWork) snapshot() changes {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return changes{
+		ops:           append([]queuedOp(nil), r.ops...),
+		creates:       append([]any(nil), r.toCreate...),
+		updates:       append([]any(nil), r.toUpdate...),
+		deletes:       append([]any(nil), r.toDelete...),
+		events:        append([]DomainEvent(nil), r.events...),
+		messages:      append([]message(nil), r.messages...),
+		afterCommit:   append([]func(){}, r.afterCommit...),
+		afterRollback: append([]func(){}, r.afterRollback...),
+		interceptors:  append([]func(PendingOp) (PendingOp, error){}, r.interceptors...),
+	}
+}
+
+// apply executes c inside tx in phase order: PriorityFirst operations, creates, updates,
+// deletes, custom operations, PriorityLast operations.
+func (r *UnitOfWork) apply(tx *gorm.DB, c changes) (err error) {
+	if r.opts.recoverPanics {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("%w: %v", ErrPanicRecovered, p)
 			}
+		}()
+	}
+	for _, setup := range r.opts.onBegin {
+		if err := setup(tx); err != nil {
+			return err
 		}
-		// 3. Apply deletes
-		for _, e := range deletes {
-			if err := tx.Delete(e).Error; err != nil {
+	}
+	c = c.resolveConditions()
+	if r.opts.tenant != nil {
+		if err := r.opts.tenant.stamp(c); err != nil {
+			return err
+		}
+	}
+	c, err = c.intercept(c.interceptors)
+	if err != nil {
+		return err
+	}
+	// 0. Apply operations that asked to run first
+	if err := c.applyOps(tx, PriorityFirst); err != nil {
+		return err
+	}
+	// 1. Apply creates
+	for _, group := range r.createGroups(c.creates) {
+		for _, e := range group {
+			r.stampActor(tx.Statement.Context, e, PhaseCreate)
+			if err := r.beforeSave(e, PhaseCreate); err != nil {
 				return err
 			}
 		}
-		// 4. Apply custom operations
-		for _, op := range deferredOps {
-			if err := op(gormTx{db: tx}); err != nil {
+		if err := r.createGroup(tx, group); err != nil {
+			return err
+		}
+		for _, e := range group {
+			if err := r.afterSave(tx, e, PhaseCreate); err != nil {
 				return err
 			}
 		}
+	}
+	// 2. Apply updates
+	for _, e := range c.updates {
+		r.stampActor(tx.Statement.Context, e, PhaseUpdate)
+		if err := r.beforeSave(e, PhaseUpdate); err != nil {
+			return err
+		}
+		if err := r.save(tx, e); err != nil { // Save handles both insert/update by PK, but we used Add above for clarity
+			return err
+		}
+		if err := r.afterSave(tx, e, PhaseUpdate); err != nil {
+			return err
+		}
+	}
+	// 3. Apply deletes
+	for _, e := range c.deletes {
+		if err := r.beforeSave(e, PhaseDelete); err != nil {
+			return err
+		}
+		if err := r.delete(tx, e); err != nil {
+			return err
+		}
+		if err := r.afterSave(tx, e, PhaseDelete); err != nil {
+			return err
+		}
+	}
+	// 4. Apply custom operations
+	if err := c.applyOps(tx, PriorityNormal); err != nil {
+		return err
+	}
+	// 5. Apply operations that asked to run last
+	return c.applyOps(tx, PriorityLast)
+}
+
+// applyOps runs c's custom operations of priority p in queue order.
+func (c changes) applyOps(tx *gorm.DB, p Priority) error {
+	for _, q := range c.ops {
+		if q.priority != p {
+			continue
+		}
+		if err := q.op(gormTx{db: tx}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// delete deletes a tracked entity. Under Tenancy, rows the tenant filter does not match make
+// it fail with ErrNotFound.
+func (r *UnitOfWork) delete(tx *gorm.DB, entity any) error {
+	res := tx.Delete(entity)
+	if res.Error != nil || r.opts.tenant == nil || tx.DryRun {
+		return res.Error
+	}
+	if n := len(structValues(reflect.ValueOf(entity))); res.RowsAffected < int64(n) {
+		return fmt.Errorf("tracker: delete %T: %w", entityOf(entity), ErrNotFound)
+	}
+	return nil
+}
+
+// beforeSave runs the WithBeforeSave hook, if any.
+func (r *UnitOfWork) beforeSave(entity any, phase string) error {
+	if r.opts.beforeSave == nil {
 		return nil
-	})
+	}
+	return r.opts.beforeSave(entity, phase)
+}
+
+// afterSave runs the WithAfterSave hook, if any.
+func (r *UnitOfWork) afterSave(tx *gorm.DB, entity any, phase string) error {
+	if r.opts.afterSave == nil {
+		return nil
+	}
+	return r.opts.afterSave(gormTx{db: tx}, entity, phase)
+}
 
+// finish runs the callbacks matching the transaction outcome, clears the queue on success
+// and publishes the recorded domain events.
+func (r *UnitOfWork) finish(ctx context.Context, c changes, txErr error) error {
 	if txErr != nil {
-		for _, cb := range afterRollback {
+		for _, cb := range c.afterRollback {
 			// best-effort and safe do not shadow txErr if callback fails
 			func() { defer func() { _ = recover() }(); cb() }()
 		}
@@ -169,10 +897,17 @@ func (r *UnitOfWork) Commit(ctx context.Context) error {
 
 	// On success, clear pending items and run after-commit callbacks
 	r.Clear()
-	for _, cb := range afterCommit {
+	for _, cb := range c.afterCommit {
 		func() { defer func() { _ = recover() }(); cb() }()
 	}
-	return nil
+	return errors.Join(r.publishMessages(ctx, c.messages), r.publishEvents(ctx, c.events))
+}
+
+// queueError returns the error recorded by a queueing method, if any.
+func (r *UnitOfWork) queueError() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.queueErr
 }
 
 // Clear discards all pending operations and tracked entities.
@@ -183,26 +918,106 @@ func (r *UnitOfWork) Clear() {
 	r.toCreate = nil
 	r.toUpdate = nil
 	r.toDelete = nil
+	r.events = nil
+	r.messages = nil
 	r.afterCommit = nil
 	r.afterRollback = nil
+	r.savepoints = nil
+	r.savepointOps = 0
+	r.queueErr = nil
 }
 
 // HasPending returns true if there are any queued operations or tracked changes.
-func (r *UnitOfWork) HasPending() bool {
+func (r *UnitOfWork) HasPending() bool { return r.PendingCount() > 0 }
+
+// PendingCount returns the total number of queued operations and tracked changes.
+func (r *UnitOfWork) PendingCount() int {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	return len(r.ops) > 0 || len(r.toCreate) > 0 || len(r.toUpdate) > 0 || len(r.toDelete) > 0
+	return len(r.ops) + len(r.toCreate) + len(r.toUpdate) + len(r.toDelete)
+}
+
+// pendingJSON is the wire shape produced by SerializeToJSON.
+type pendingJSON struct {
+	Creates  []any `json:"creates"`
+	Updates  []any `json:"updates"`
+	Deletes  []any `json:"deletes"`
+	OpsCount int   `json:"ops_count"`
+}
+
+// SerializeToJSON marshals the pending change set without committing it, e.g. for change previews.
+// Custom operations cannot be serialized, so only their number is reported.
+func (r *UnitOfWork) SerializeToJSON(_ context.Context) ([]byte, error) {
+	c := r.snapshot()
+	return json.Marshal(pendingJSON{
+		Creates:  c.createdEntities(),
+		Updates:  append([]any{}, c.updates...),
+		Deletes:  append([]any{}, c.deletes...),
+		OpsCount: len(c.ops),
+	})
+}
+
+// WrapTransaction runs fn in a transaction of its own, committing if it returns nil and
+// rolling back otherwise. It is a low-level escape hatch: pending UoW work is not included.
+func (r *UnitOfWork) WrapTransaction(ctx context.Context, fn func(tx Tx) error) error {
+	if err := r.writable(); err != nil {
+		return err
+	}
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error { return fn(gormTx{db: tx}) })
+}
+
+// Transactional is WrapTransaction for transactions that compute a value, such as the
+// primary key assigned to a new row. The value is returned only if the commit succeeds.
+func Transactional[T any](ctx context.Context, uow *UnitOfWork, fn func(tx Tx) (T, error)) (T, error) {
+	var out T
+	err := uow.WrapTransaction(ctx, func(tx Tx) error {
+		var err error
+		out, err = fn(tx)
+		return err
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return out, nil
+}
+
+// RunInBackground runs fn in a new goroutine, for non-critical writes such as analytics that
+// should not delay an HTTP response, and returns a channel that receives fn's result and
+// is then closed. fn gets a context detached from ctx: it keeps ctx's values but is not
+// canceled when ctx is, so the work outlives the request. fn should only use the data it
+// is given explicitly. With WithPanicRecovery enabled on uow, a panic in fn is sent as an
+// error wrapping ErrPanicRecovered instead of crashing the program.
+func RunInBackground(ctx context.Context, uow *UnitOfWork, fn func(ctx context.Context) error) <-chan error {
+	done := make(chan error, 1)
+	bg := context.WithoutCancel(ctx)
+	go func() {
+		defer close(done)
+		if uow.opts.recoverPanics {
+			defer func() {
+				if p := recover(); p != nil {
+					done <- fmt.Errorf("%w: %v", ErrPanicRecovered, p)
+				}
+			}()
+		}
+		done <- fn(bg)
+	}()
+	return done
 }
 
 // First fetches the first record that matches the conditions into out, without exposing GORM.
 func (r *UnitOfWork) First(ctx context.Context, out any, conds ...any) error {
-	return r.root.WithContext(ctx).First(out, conds...).Error
+	return r.conn(ctx).First(out, conds...).Error
 }
 
 // PreloadFirst preloads associations and fetches the first record by primary key.
 func (r *UnitOfWork) PreloadFirst(ctx context.Context, out any, id any, preloads ...string) error {
-	db := r.root.WithContext(ctx)
+	r.opts.n1.reset()
+	db := r.conn(ctx)
 	for _, p := range preloads {
+		if _, scoped := db.Statement.Preloads[p]; scoped {
+			continue // keep the conditions set by PreloadWithOrder
+		}
 		db = db.Preload(p)
 	}
 	return db.First(out, id).Error
@@ -0,0 +1,362 @@
+package tracker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type configItem struct {
+	Name string
+	ID   uint
+}
+
+func TestNewWithGormConfigPrepareStmt(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *gorm.Config
+		want bool
+	}{
+		{name: "prepared", cfg: &gorm.Config{PrepareStmt: true}, want: true},
+		{name: "plain", cfg: &gorm.Config{}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow, err := NewWithGormConfig(newTestDB(t), tt.cfg, nil)
+			if err != nil {
+				t.Fatalf("NewWithGormConfig: %v", err)
+			}
+			_, got := uow.mustRoot().ConnPool.(*gorm.PreparedStmtDB)
+			if got != tt.want {
+				t.Fatalf("prepared statement cache active = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewWithGormConfigRootCache(t *testing.T) {
+	db := newTestDB(t)
+	t.Cleanup(func() { _ = db.Close(); CompactGormRoots(context.Background()) })
+
+	var logs []*bytes.Buffer
+	open := func(cfg *gorm.Config) *UnitOfWork {
+		t.Helper()
+		uow, err := NewWithGormConfig(db, cfg, nil, WithAutoMigrate(&configItem{}))
+		if err != nil {
+			t.Fatalf("NewWithGormConfig: %v", err)
+		}
+		return uow
+	}
+	freshLogger := func() *gorm.Config {
+		buf := new(bytes.Buffer)
+		logs = append(logs, buf)
+		return &gorm.Config{Logger: logger.New(log.New(buf, "", 0), logger.Config{LogLevel: logger.Info})}
+	}
+
+	open(freshLogger())
+	base := GormRootCount()
+	uows := make([]*UnitOfWork, 0, 10)
+	for range 10 {
+		uows = append(uows, open(freshLogger()))
+	}
+	if got := GormRootCount(); got != base {
+		t.Fatalf("GormRootCount = %d after repeated configs, want %d", got, base)
+	}
+
+	uows[3].Add(&configItem{Name: "logged"})
+	mustCommit(t, uows[3])
+	if !strings.Contains(logs[4].String(), "INSERT") {
+		t.Fatalf("UnitOfWork did not log through its own Logger: %q", logs[4].String())
+	}
+	if strings.Contains(logs[5].String(), "INSERT") {
+		t.Fatal("INSERT logged through another UnitOfWork's Logger")
+	}
+
+	open(&gorm.Config{SkipDefaultTransaction: true})
+	if got := GormRootCount(); got != base+1 {
+		t.Fatalf("GormRootCount = %d for a different setting, want %d", got, base+1)
+	}
+}
+
+type queuedItem struct {
+	Name      string
+	DeletedAt gorm.DeletedAt
+	ID        uint
+}
+
+func TestPendingCount(t *testing.T) {
+	tests := []struct {
+		queue func(uow *UnitOfWork)
+		name  string
+		want  int
+	}{
+		{name: "empty", queue: func(*UnitOfWork) {}, want: 0},
+		{name: "every kind", queue: func(uow *UnitOfWork) {
+			uow.Add(&queuedItem{Name: "a"})
+			uow.Add(&queuedItem{Name: "b"})
+			uow.Update(&queuedItem{ID: 1})
+			uow.RegisterDelete(&queuedItem{ID: 2})
+			uow.Do(func(Tx) error { return nil })
+		}, want: 5},
+		{name: "cleared", queue: func(uow *UnitOfWork) {
+			uow.Add(&queuedItem{Name: "a"})
+			uow.Clear()
+		}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&queuedItem{}})
+			tt.queue(uow)
+			if got := uow.PendingCount(); got != tt.want {
+				t.Fatalf("PendingCount = %d, want %d", got, tt.want)
+			}
+			if got := uow.HasPending(); got != (tt.want > 0) {
+				t.Fatalf("HasPending = %t, want %t", got, tt.want > 0)
+			}
+		})
+	}
+}
+
+func TestBeforeSave(t *testing.T) {
+	errVeto := errors.New("veto")
+	tests := []struct {
+		veto       error
+		name       string
+		wantPhases []string
+		wantCount  int64
+	}{
+		{name: "create and update", wantPhases: []string{"create", "update"}, wantCount: 1},
+		{name: "veto", veto: errVeto, wantPhases: []string{"create"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			var phases []string
+			uow := newTestUoW(t, []any{&queuedItem{}}, WithBeforeSave(func(_ any, phase string) error {
+				phases = append(phases, phase)
+				return tt.veto
+			}))
+			item := &queuedItem{Name: "a"}
+			uow.Add(item)
+			if err := uow.Commit(ctx); !errors.Is(err, tt.veto) {
+				t.Fatalf("Commit = %v, want %v", err, tt.veto)
+			}
+			if tt.veto == nil {
+				item.Name = "b"
+				uow.Update(item)
+				mustCommit(t, uow)
+			}
+			if strings.Join(phases, ",") != strings.Join(tt.wantPhases, ",") {
+				t.Fatalf("phases = %v, want %v", phases, tt.wantPhases)
+			}
+			if n, err := uow.Count(ctx, &queuedItem{}); err != nil || n != tt.wantCount {
+				t.Fatalf("Count = %d, %v, want %d", n, err, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestIntercept(t *testing.T) {
+	errRejected := errors.New("rejected")
+	tests := []struct {
+		fn       func(op PendingOp) (PendingOp, error)
+		wantErr  error
+		name     string
+		wantName string
+	}{
+		{
+			name: "rewrites entity",
+			fn: func(op PendingOp) (PendingOp, error) {
+				if it, ok := op.Entity.(*queuedItem); ok {
+					it.Name = strings.ToUpper(it.Name)
+				}
+				return op, nil
+			},
+			wantName: "ADA",
+		},
+		{
+			name:    "aborts commit",
+			fn:      func(op PendingOp) (PendingOp, error) { return op, errRejected },
+			wantErr: errRejected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			uow := newTestUoW(t, []any{&queuedItem{}})
+			uow.Intercept(tt.fn)
+			item := &queuedItem{Name: "ada"}
+			uow.Add(item)
+			if err := uow.Commit(ctx); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Commit = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if n, _ := uow.Count(ctx, &queuedItem{}); n != 0 {
+					t.Fatalf("Count = %d after an aborted commit, want 0", n)
+				}
+				return
+			}
+			var got queuedItem
+			if err := uow.First(ctx, &got, item.ID); err != nil || got.Name != tt.wantName {
+				t.Fatalf("First = %+v, %v, want Name %q", got, err, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestConditionalAdd(t *testing.T) {
+	tests := []struct {
+		name string
+		cond bool
+		want int64
+	}{
+		{name: "true", cond: true, want: 1},
+		{name: "false", cond: false, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&queuedItem{}})
+			evaluated := false
+			uow.ConditionalAdd(&queuedItem{Name: "maybe"}, func() bool { evaluated = true; return tt.cond })
+			if evaluated {
+				t.Fatal("condition evaluated before Commit")
+			}
+			mustCommit(t, uow)
+			if n, err := uow.Count(context.Background(), &queuedItem{}); err != nil || n != tt.want {
+				t.Fatalf("Count = %d, %v, want %d", n, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxSaveChangesCallDepth(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		depth   int
+		wantErr error
+	}{
+		{name: "default rejects reentrancy", depth: 0, wantErr: ErrMaxDepthExceeded},
+		{name: "depth 2 allows one nested commit", depth: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []Option
+			if tt.depth > 0 {
+				opts = append(opts, WithMaxSaveChangesCallDepth(tt.depth))
+			}
+			uow := newTestUoW(t, []any{&queuedItem{}}, opts...)
+			var nestedErr error
+			uow.Add(&queuedItem{Name: "outer"})
+			uow.AfterCommit(func() {
+				uow.Add(&queuedItem{Name: "nested"})
+				nestedErr = uow.Commit(ctx)
+			})
+			mustCommit(t, uow)
+			if !errors.Is(nestedErr, tt.wantErr) {
+				t.Fatalf("nested Commit = %v, want %v", nestedErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAfterSave(t *testing.T) {
+	errAfter := errors.New("after")
+	tests := []struct {
+		err        error
+		name       string
+		wantPhases []string
+		wantCount  int64
+	}{
+		{name: "create and update", wantPhases: []string{"create", "update"}, wantCount: 1},
+		{name: "error rolls back", err: errAfter, wantPhases: []string{"create"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			var phases []string
+			uow := newTestUoW(t, []any{&queuedItem{}}, WithAfterSave(func(_ Tx, e any, phase string) error {
+				if e.(*queuedItem).ID == 0 {
+					t.Errorf("after %s: entity has no ID yet", phase)
+				}
+				phases = append(phases, phase)
+				return tt.err
+			}))
+			item := &queuedItem{Name: "a"}
+			uow.Add(item)
+			if err := uow.Commit(ctx); !errors.Is(err, tt.err) {
+				t.Fatalf("Commit = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil {
+				item.Name = "b"
+				uow.Update(item)
+				mustCommit(t, uow)
+			}
+			if strings.Join(phases, ",") != strings.Join(tt.wantPhases, ",") {
+				t.Fatalf("phases = %v, want %v", phases, tt.wantPhases)
+			}
+			if n, err := uow.Count(ctx, &queuedItem{}); err != nil || n != tt.wantCount {
+				t.Fatalf("Count = %d, %v, want %d", n, err, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	ctx := context.Background()
+	uow := newTestUoW(t, []any{&queuedItem{}})
+	item := &queuedItem{Name: "a"}
+	uow.Add(item)
+	mustCommit(t, uow)
+
+	uow.RegisterDelete(item)
+	mustCommit(t, uow)
+	if err := uow.First(ctx, &queuedItem{}, item.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("First after delete = %v, want ErrNotFound", err)
+	}
+	var raw int64
+	if err := uow.mustRoot().Unscoped().Model(&queuedItem{}).Count(&raw).Error; err != nil || raw != 1 {
+		t.Fatalf("unscoped Count = %d, %v, want the soft-deleted row", raw, err)
+	}
+
+	uow.Undelete(item)
+	mustCommit(t, uow)
+	if err := uow.First(ctx, &queuedItem{}, item.ID); err != nil {
+		t.Fatalf("First after Undelete: %v", err)
+	}
+}
+
+func TestTimedDo(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		sleep    time.Duration
+		wantErr  error
+		wantRows int64
+	}{
+		{name: "within deadline", wantRows: 1},
+		{name: "exceeds deadline", sleep: 100 * time.Millisecond, wantErr: context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&configItem{}})
+			uow.TimedDo(10*time.Millisecond, func(tx Tx) error {
+				time.Sleep(tt.sleep)
+				return tx.Create(&configItem{Name: "timed"})
+			})
+			if err := uow.Commit(ctx); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Commit = %v, want %v", err, tt.wantErr)
+			}
+			if n, err := uow.Count(ctx, &configItem{}); err != nil || n != tt.wantRows {
+				t.Fatalf("Count = %d, %v, want %d", n, err, tt.wantRows)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// multiInsertBatch is how many rows MultiInsert puts in one INSERT statement.
+const multiInsertBatch = 500
+
+// CompareAndSwap sets col to newValue on the T row with primary key id, but only while col
+// still equals expected. It runs immediately as a single UPDATE, outside the pending queue,
+// and reports whether the swap happened.
+func CompareAndSwap[T any](ctx context.Context, uow *UnitOfWork, id uint, col string, expected, newValue any) (bool, error) {
+	if err := uow.writable(); err != nil {
+		return false, err
+	}
+	res := uow.conn(ctx).Model(new(T)).
+		Where(clause.Eq{Column: clause.PrimaryColumn, Value: id}).
+		Where(clause.Eq{Column: clause.Column{Name: col}, Value: expected}).
+		Update(col, newValue)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// BatchDelete deletes the T rows with the given primary keys in a single statement, running
+// immediately outside the pending queue, and returns the number of rows affected. Models with
+// a gorm.DeletedAt field are soft-deleted. An empty ids slice is a no-op.
+func BatchDelete[T any](ctx context.Context, uow *UnitOfWork, ids []uint) (int64, error) {
+	if err := uow.writable(); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	res := uow.conn(ctx).Delete(new(T), ids)
+	return res.RowsAffected, res.Error
+}
+
+// MultiInsert inserts rows into table without a Go model, for schema-less pipelines. The
+// keys of the first row are the columns; every other row must use a subset of them (missing
+// columns are inserted as NULL). Rows are written with multi-row INSERTs in one transaction,
+// immediately and outside the pending queue. It returns the number of rows inserted.
+func MultiInsert(ctx context.Context, uow *UnitOfWork, table string, rows []map[string]any) (int64, error) {
+	if err := uow.writable(); err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	names := make([]string, 0, len(rows[0]))
+	for k := range rows[0] {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+	cols := make([]clause.Column, len(names))
+	for i, n := range names {
+		cols[i] = clause.Column{Name: n}
+	}
+	values := make([][]any, len(rows))
+	for i, row := range rows {
+		values[i] = make([]any, len(names))
+		for k, v := range row {
+			j, ok := slices.BinarySearch(names, k)
+			if !ok {
+				return 0, fmt.Errorf("tracker: MultiInsert: row %d has column %q not in the first row", i, k)
+			}
+			values[i][j] = v
+		}
+	}
+
+	var n int64
+	err := uow.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(values); start += multiInsertBatch {
+			batch := values[start:min(start+multiInsertBatch, len(values))]
+			stmt := &gorm.Statement{DB: tx, Clauses: map[string]clause.Clause{}}
+			stmt.AddClause(clause.Insert{Table: clause.Table{Name: table}})
+			stmt.AddClause(clause.Values{Columns: cols, Values: batch})
+			stmt.Build("INSERT", "VALUES")
+			res := tx.Exec(stmt.SQL.String(), stmt.Vars...)
+			if res.Error != nil {
+				return res.Error
+			}
+			n += res.RowsAffected
+		}
+		return nil
+	})
+	return n, err
+}
+
+// CascadeDelete loads the T row with primary key id together with assocs and queues the
+// deletion of the associated records followed by the row itself, so the next Commit removes
+// them in one transaction without relying on ON DELETE CASCADE. Has-one and has-many
+// records are deleted; for many-to-many associations only the join rows are removed.
+// Belongs-to associations are rejected. It returns ErrNotFound if the row does not exist.
+func CascadeDelete[T any](ctx context.Context, uow *UnitOfWork, id uint, assocs []string) error {
+	root := new(T)
+	if err := uow.PreloadFirst(ctx, root, id, assocs...); err != nil {
+		return err
+	}
+	stmt := &gorm.Statement{DB: uow.conn(ctx)}
+	if err := stmt.Parse(root); err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(root).Elem()
+	for _, assoc := range assocs {
+		rel, ok := stmt.Schema.Relationships.Relations[assoc]
+		if !ok {
+			return fmt.Errorf("tracker: CascadeDelete: %s has no association %s", stmt.Schema.Name, assoc)
+		}
+		switch rel.Type {
+		case schema.HasOne, schema.HasMany:
+			if v := rel.Field.ReflectValueOf(ctx, rv); !v.IsZero() && (v.Kind() != reflect.Slice || v.Len() > 0) {
+				uow.RegisterDelete(reflect.Indirect(v).Addr().Interface())
+			}
+		case schema.Many2Many:
+			uow.DoWithPriority(func(tx Tx) error {
+				g, ok := tx.(interface{ gormDB() *gorm.DB })
+				if !ok {
+					return fmt.Errorf("tracker: CascadeDelete: cannot clear %s outside a tracker transaction", assoc)
+				}
+				return g.gormDB().Model(root).Association(assoc).Clear()
+			}, PriorityFirst)
+		default:
+			return fmt.Errorf("tracker: CascadeDelete: %s relation %s is not supported", rel.Type, assoc)
+		}
+	}
+	uow.RegisterDelete(root)
+	return nil
+}
+
+// UpdateFromJoin updates the T rows joined to J rows by joinCond and matching cond, and
+// returns the number of rows updated. Values in updates are bound as parameters unless
+// they are expressions, so gorm.Expr can copy columns from the joined table, e.g.
+//
+//	UpdateFromJoin[Order, Customer](ctx, uow, "orders.customer_id = customers.id",
+//		map[string]any{"status": gorm.Expr("customers.preferred_status")},
+//		"customers.tier = ?", "GOLD")
+//
+// It runs immediately, outside the pending queue, as UPDATE ... FROM on PostgreSQL and
+// SQLite (3.33 and later) and UPDATE ... JOIN on MySQL.
+func UpdateFromJoin[T, J any](ctx context.Context, uow *UnitOfWork, joinCond string, updates map[string]any, cond string, args ...any) (int64, error) {
+	if err := uow.writable(); err != nil {
+		return 0, err
+	}
+	db := uow.conn(ctx)
+	tables := make([]string, 2)
+	for i, model := range []any{new(T), new(J)} {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return 0, err
+		}
+		tables[i] = stmt.Quote(stmt.Schema.Table)
+	}
+	target, joined := tables[0], tables[1]
+	dialect := db.Dialector.Name()
+
+	cols := make([]string, 0, len(updates))
+	for col := range updates {
+		cols = append(cols, col)
+	}
+	slices.Sort(cols)
+	sets := make([]string, len(cols))
+	vars := make([]any, 0, len(cols)+len(args))
+	for i, col := range cols {
+		name := db.Statement.Quote(col)
+		if dialect == "mysql" {
+			name = target + "." + name
+		}
+		sets[i] = name + " = ?"
+		vars = append(vars, updates[col])
+	}
+	where := joinCond
+	if cond != "" {
+		where += " AND (" + cond + ")"
+		vars = append(vars, args...)
+	}
+
+	var sql string
+	switch dialect {
+	case "mysql":
+		sql = fmt.Sprintf("UPDATE %s JOIN %s ON %s SET %s", target, joined, where, strings.Join(sets, ", "))
+	default:
+		sql = fmt.Sprintf("UPDATE %s SET %s FROM %s WHERE %s", target, strings.Join(sets, ", "), joined, where)
+	}
+	res := db.Exec(sql, vars...)
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,103 @@
+package tracker
+
+import (
+	"context"
+	"testing"
+)
+
+type swapAccount struct {
+	Status string
+	ID     uint
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name       string
+		expected   string
+		wantStatus string
+		wantSwap   bool
+	}{
+		{name: "matches", expected: "open", wantSwap: true, wantStatus: "closed"},
+		{name: "stale", expected: "pending", wantSwap: false, wantStatus: "open"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&swapAccount{}})
+			acc := &swapAccount{Status: "open"}
+			uow.Add(acc)
+			mustCommit(t, uow)
+
+			swapped, err := CompareAndSwap[swapAccount](ctx, uow, acc.ID, "status", tt.expected, "closed")
+			if err != nil || swapped != tt.wantSwap {
+				t.Fatalf("CompareAndSwap = %t, %v, want %t", swapped, err, tt.wantSwap)
+			}
+			var got swapAccount
+			if err := uow.First(ctx, &got, acc.ID); err != nil || got.Status != tt.wantStatus {
+				t.Fatalf("Status = %q, %v, want %q", got.Status, err, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestBatchDelete(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		ids       func(accs []*swapAccount) []uint
+		wantRows  int64
+		wantCount int64
+	}{
+		{name: "empty", ids: func([]*swapAccount) []uint { return nil }, wantRows: 0, wantCount: 3},
+		{name: "two", ids: func(a []*swapAccount) []uint { return []uint{a[0].ID, a[2].ID} }, wantRows: 2, wantCount: 1},
+		{name: "missing", ids: func([]*swapAccount) []uint { return []uint{999} }, wantRows: 0, wantCount: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&swapAccount{}})
+			accs := []*swapAccount{{Status: "a"}, {Status: "b"}, {Status: "c"}}
+			for _, a := range accs {
+				uow.Add(a)
+			}
+			mustCommit(t, uow)
+
+			n, err := BatchDelete[swapAccount](ctx, uow, tt.ids(accs))
+			if err != nil || n != tt.wantRows {
+				t.Fatalf("BatchDelete = %d, %v, want %d", n, err, tt.wantRows)
+			}
+			if c, err := uow.Count(ctx, &swapAccount{}); err != nil || c != tt.wantCount {
+				t.Fatalf("Count = %d, %v, want %d", c, err, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestMultiInsert(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		rows    []map[string]any
+		want    int64
+		wantErr bool
+	}{
+		{name: "empty", rows: nil, want: 0},
+		{name: "rows", rows: []map[string]any{{"status": "a"}, {"status": "b"}}, want: 2},
+		{name: "subset of columns", rows: []map[string]any{{"status": "a", "id": 7}, {"status": "b"}}, want: 2},
+		{name: "unknown column", rows: []map[string]any{{"status": "a"}, {"other": "b"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uow := newTestUoW(t, []any{&swapAccount{}})
+			n, err := MultiInsert(ctx, uow, "swap_accounts", tt.rows)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MultiInsert = %v, wantErr %t", err, tt.wantErr)
+			}
+			if n != tt.want {
+				t.Fatalf("MultiInsert = %d, want %d", n, tt.want)
+			}
+			if c, err := uow.Count(ctx, &swapAccount{}); err != nil || c != tt.want {
+				t.Fatalf("Count = %d, %v, want %d", c, err, tt.want)
+			}
+		})
+	}
+}